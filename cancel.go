@@ -0,0 +1,119 @@
+package jsonrpc2
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/goccy/go-json"
+)
+
+// DefaultCancelMethod is the default method name for cancellation notifications.
+// This is the same as Language Server Protocol.
+const DefaultCancelMethod = "$/cancelRequest"
+
+// CancelParams is the parameters of cancellation notifications.
+type CancelParams struct {
+	ID *ID `json:"id"`
+}
+
+// errCancelledByPeer is used as a cause of context cancellation when the peer cancels a request.
+var errCancelledByPeer = errors.New("jsonrpc2: request cancelled by peer")
+
+type inflightKey struct{}
+
+type inflightEntry struct {
+	cancel context.CancelCauseFunc
+}
+
+// inflightRequests tracks running requests in a connection to cancel them by ID.
+type inflightRequests struct {
+	mu sync.Mutex
+	m  map[string]*inflightEntry
+}
+
+// withInflightRequests returns a context that tracks running requests.
+func withInflightRequests(ctx context.Context) context.Context {
+	return context.WithValue(ctx, inflightKey{}, &inflightRequests{
+		m: make(map[string]*inflightEntry),
+	})
+}
+
+// trackRequest registers a request to the running requests of the connection in `ctx`.
+// It returns a context that is cancelled when the peer cancels the request, and a function to call when the request is done.
+// Notifications and requests from connections that do not track requests are not registered.
+func trackRequest(ctx context.Context, r RawRequest) (context.Context, func()) {
+	inflight, ok := ctx.Value(inflightKey{}).(*inflightRequests)
+	if !ok || r.ID == nil {
+		return ctx, func() {}
+	}
+	return inflight.start(ctx, r.ID)
+}
+
+// start registers a request and returns a context that is cancelled when the peer cancels the request.
+// Please call the returned function when the request is done.
+func (r *inflightRequests) start(ctx context.Context, id *ID) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	entry := &inflightEntry{cancel: cancel}
+	key := id.String()
+
+	r.mu.Lock()
+	r.m[key] = entry
+	r.mu.Unlock()
+
+	return ctx, func() {
+		r.mu.Lock()
+		if r.m[key] == entry {
+			delete(r.m, key)
+		}
+		r.mu.Unlock()
+
+		cancel(nil)
+	}
+}
+
+// cancel cancels the running request that has the given ID.
+func (r *inflightRequests) cancel(id *ID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry, ok := r.m[id.String()]; ok {
+		entry.cancel(errCancelledByPeer)
+	}
+}
+
+// handleCancel handles a cancellation notification.
+// It returns false if `r` is not a cancellation notification, or the connection does not track requests.
+func (s *Server) handleCancel(ctx context.Context, r RawRequest) bool {
+	if s.cancelMethod == "" || r.Method != s.cancelMethod {
+		return false
+	}
+
+	inflight, ok := ctx.Value(inflightKey{}).(*inflightRequests)
+	if !ok {
+		return false
+	}
+
+	var params CancelParams
+	if err := json.Unmarshal(r.Params, &params); err == nil && params.ID != nil {
+		inflight.cancel(params.ID)
+	}
+
+	return true
+}
+
+// sendCancel sends cancellation notifications for the given IDs.
+func (c *Client) sendCancel(ids []*ID) {
+	if c.cancelMethod == "" {
+		return
+	}
+
+	for _, id := range ids {
+		var params any = CancelParams{ID: id}
+		writeMessage(c.w, Request[any]{
+			Jsonrpc: VersionValue,
+			Method:  c.cancelMethod,
+			Params:  &params,
+		})
+	}
+}
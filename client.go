@@ -3,19 +3,48 @@ package jsonrpc2
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/goccy/go-json"
 )
 
+var (
+	ErrClientClosed = errors.New("jsonrpc2: client closed")
+)
+
+// ConnectionError is an error that reported when the connection to the server is lost.
+//
+// All pending calls fail with this error, and new calls are rejected with it.
+type ConnectionError struct {
+	// Err is the error that the reader reported, such as io.EOF.
+	Err error
+}
+
+func (e *ConnectionError) Error() string {
+	return fmt.Sprintf("jsonrpc2: connection lost: %s", e.Err)
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
 // Client is a JSON-RPC 2.0 client.
 type Client struct {
+	caller
+
 	mu     sync.Mutex
-	rw     io.ReadWriter
-	ch     map[int64]chan<- Response[json.RawMessage]
+	r      FrameReader
+	w      *lockedFrameWriter
+	ch     map[string]chan<- Response[json.RawMessage]
 	closer func()
-	nextID int64
+	done   chan struct{}
+	err    error
+
+	cancelMethod string
 }
 
 // NewClient creates a new JSON-RPC 2.0 client.
@@ -24,54 +53,151 @@ type Client struct {
 //
 // This function starts a goroutine to read responses from the server.
 // Please make sure to call `Close` to stop the goroutine when you are done.
-func NewClient(rw io.ReadWriter) *Client {
+func NewClient(rw io.ReadWriter, opts ...ClientOption) *Client {
 	if rw == nil {
 		panic("jsonrpc2: rw for jsonrpc2.NewClient is nil")
 	}
 
+	conf := newClientConfig(opts)
+
 	ctx, cancel := context.WithCancel(context.Background())
 
-	client := &Client{
-		rw:     rw,
-		ch:     make(map[int64]chan<- Response[json.RawMessage]),
-		closer: cancel,
-	}
+	client := newClient(conf, newFrameReader(conf.framing, rw), newFrameWriter(conf.framing, rw), cancel)
 
 	go client.run(ctx)
 
 	return client
 }
 
+// newClient creates a new client without starting the goroutine to read responses.
+//
+// All writes to `w` are serialized by the client, so the client can be used from multiple goroutines.
+func newClient(conf clientConfig, r FrameReader, w FrameWriter, closer func()) *Client {
+	c := &Client{
+		r:            r,
+		w:            &lockedFrameWriter{w: w},
+		ch:           make(map[string]chan<- Response[json.RawMessage]),
+		closer:       closer,
+		done:         make(chan struct{}),
+		cancelMethod: conf.cancelMethod,
+	}
+	c.caller.init(conf, c.roundTrip, c.roundTripBatch)
+	return c
+}
+
+// ClientOption is a type for client options.
+//
+// The same options can be used for `NewClient`, `NewConn`, `NewHTTPClient`, and `NewReconnectingClient`.
+// Options that are not related to the transport are ignored, such as `WithClientFraming` for `NewHTTPClient`.
+type ClientOption func(*clientConfig)
+
+type clientConfig struct {
+	framing           Framing
+	cancelMethod      string
+	interceptors      []Interceptor
+	batchInterceptors []BatchInterceptor
+	idGenerator       IDGenerator
+
+	backoffInitial     time.Duration
+	backoffMax         time.Duration
+	replayPolicy       ReplayPolicy
+	notificationBuffer int
+	stateHandler       func(ConnectionState, error)
+
+	stderrLogger *slog.Logger
+	gracePeriod  time.Duration
+
+	serverOptions []ServerOption
+}
+
+func newClientConfig(opts []ClientOption) clientConfig {
+	conf := clientConfig{
+		framing:      StreamFraming{},
+		cancelMethod: DefaultCancelMethod,
+
+		backoffInitial:     100 * time.Millisecond,
+		backoffMax:         30 * time.Second,
+		notificationBuffer: 100,
+
+		gracePeriod: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&conf)
+	}
+	if conf.idGenerator == nil {
+		conf.idGenerator = SequentialIDGenerator()
+	}
+	return conf
+}
+
+// WithClientFraming specifies the framing to split the stream into messages.
+// If this option is not specified, `StreamFraming` is used.
+func WithClientFraming(f Framing) ClientOption {
+	return func(c *clientConfig) {
+		c.framing = f
+	}
+}
+
+// WithClientCancelMethod specifies the method name for cancellation notifications.
+// If this option is not specified, `DefaultCancelMethod` is used.
+// An empty string disables the cancellation.
+//
+// When the context of `Client.Call` or `Client.Batch` is cancelled before the response arrives,
+// the client sends a notification with `CancelParams` to the server.
+func WithClientCancelMethod(name string) ClientOption {
+	return func(c *clientConfig) {
+		c.cancelMethod = name
+	}
+}
+
 func (c *Client) onResponse(r Response[json.RawMessage]) {
 	if r.ID == nil {
 		return
 	}
-	id := r.ID.i64
-	if id == nil {
-		return
-	}
+	key := r.ID.String()
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	ch, ok := c.ch[*id]
+	ch, ok := c.ch[key]
 	if ok {
-		delete(c.ch, *id)
+		delete(c.ch, key)
 		ch <- r
 		close(ch)
 	}
 }
 
-func (c *Client) run(ctx context.Context) {
-	dec := json.NewDecoder(c.rw)
+// readFrame reads the next frame, skipping malformed ones.
+// Malformed frames are reported to `onInvalid` if it is not nil.
+// If the client is closed or the connection is lost, it stops the client and returns false.
+func (c *Client) readFrame(ctx context.Context, onInvalid func()) ([]byte, bool) {
+	for {
+		data, err := c.r.ReadFrame()
+		if ctx.Err() != nil {
+			c.shutdown(ErrClientClosed)
+			return nil, false
+		} else if errors.Is(err, ErrInvalidFrame) {
+			if onInvalid != nil {
+				onInvalid()
+			}
+			continue
+		} else if err != nil {
+			c.shutdown(&ConnectionError{Err: err})
+			return nil, false
+		}
+		return data, true
+	}
+}
 
+func (c *Client) run(ctx context.Context) {
 	for {
-		var res messageList[Response[json.RawMessage]]
+		data, ok := c.readFrame(ctx, nil)
+		if !ok {
+			return
+		}
 
-		err := dec.DecodeContext(ctx, &res)
-		if errors.Is(err, io.EOF) {
-			break
-		} else if err != nil {
+		var res messageList[Response[json.RawMessage]]
+		if err := json.Unmarshal(data, &res); err != nil {
 			continue
 		}
 
@@ -81,71 +207,197 @@ func (c *Client) run(ctx context.Context) {
 	}
 }
 
+// shutdown stops accepting calls and fails all pending calls with `err`.
+// Only the first error is kept if it is called multiple times.
+func (c *Client) shutdown(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return
+	}
+	c.err = err
+
+	for id, ch := range c.ch {
+		delete(c.ch, id)
+		close(ch)
+	}
+
+	close(c.done)
+}
+
 // Close stops the client.
 //
 // A client cannot be used after it is closed.
+// Pending and new calls fail with `ErrClientClosed`.
 func (c *Client) Close() error {
 	c.closer()
+	c.shutdown(ErrClientClosed)
+	return nil
+}
+
+// Done returns a channel that is closed when the client stops,
+// either because `Close` is called or because the connection is lost.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
 
+// Err returns the reason why the client stopped.
+//
+// It returns nil while the client is running, `ErrClientClosed` after `Close` is called,
+// and `*ConnectionError` if the connection is lost.
+func (c *Client) Err() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	return c.err
+}
 
-	for id, ch := range c.ch {
-		delete(c.ch, id)
-		close(ch)
+// register starts waiting for the response of the given ID.
+//
+// Pending calls are keyed by `ID.String`, so integer and string IDs never collide.
+func (c *Client) register(id *ID) (chan Response[json.RawMessage], error) {
+	if id.Raw() == nil {
+		return nil, fmt.Errorf("jsonrpc2: ID of a request must not be null")
 	}
+	key := id.String()
 
-	return nil
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return nil, c.err
+	}
+	if _, ok := c.ch[key]; ok {
+		return nil, fmt.Errorf("jsonrpc2: duplicated ID: %s", key)
+	}
+
+	ch := make(chan Response[json.RawMessage], 1)
+	c.ch[key] = ch
+
+	return ch, nil
 }
 
-func (c *Client) makeChan() (id int64, ch chan Response[json.RawMessage]) {
+// forget stops waiting for the response of the given ID.
+// It returns false if the response has already arrived.
+func (c *Client) forget(id *ID) bool {
+	key := id.String()
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	id = c.nextID
-	c.nextID++
-	ch = make(chan Response[json.RawMessage], 1)
-	c.ch[id] = ch
+	ch, ok := c.ch[key]
+	if ok {
+		delete(c.ch, key)
+		close(ch)
+	}
+	return ok
+}
+
+// roundTrip is the Invoker that sends a request through the stream.
+func (c *Client) roundTrip(ctx context.Context, req *Request[any]) (*Response[json.RawMessage], error) {
+	if err := c.Err(); err != nil {
+		return nil, err
+	}
+
+	if req.ID == nil {
+		return nil, writeMessage(c.w, wireRequest(req))
+	}
 
-	return
+	ch, err := c.register(req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeMessage(c.w, wireRequest(req)); err != nil {
+		c.forget(req.ID)
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		if c.forget(req.ID) {
+			go c.sendCancel([]*ID{req.ID})
+		}
+		return nil, ctx.Err()
+	case res, ok := <-ch:
+		if !ok {
+			return nil, c.Err()
+		}
+		return &res, nil
+	}
 }
 
-// Call calls a method on the server.
-//
-// The response from the server is unmarshaled into the `result` parameter.
-// If you do not need the response, use `Notify` instead.
-func (c *Client) Call(ctx context.Context, name string, params any, result any) error {
-	id, ch := c.makeChan()
+// roundTripBatch is the BatchInvoker that sends requests through the stream.
+func (c *Client) roundTripBatch(ctx context.Context, reqs []*Request[any]) ([]*Response[json.RawMessage], error) {
+	if err := c.Err(); err != nil {
+		return nil, err
+	}
 
-	req := Request[any]{
-		Jsonrpc: VersionValue,
-		Method:  name,
-		Params:  &params,
-		ID:      Int64ID(id),
+	req := messageList[Request[any]]{
+		IsBatch:  true,
+		Messages: make([]Request[any], len(reqs)),
 	}
 
-	if _, err := req.WriteTo(c.rw); err != nil {
-		c.mu.Lock()
-		defer c.mu.Unlock()
+	var ids []*ID
+	chs := make([]chan Response[json.RawMessage], len(reqs))
 
-		delete(c.ch, id)
-		close(ch)
+	destroy := func() (pending []*ID) {
+		for _, id := range ids {
+			if c.forget(id) {
+				pending = append(pending, id)
+			}
+		}
+		return
+	}
 
-		return err
+	for i, r := range reqs {
+		if r.ID != nil {
+			ch, err := c.register(r.ID)
+			if err != nil {
+				destroy()
+				return nil, err
+			}
+			ids = append(ids, r.ID)
+			chs[i] = ch
+		}
+
+		req.Messages[i] = wireRequest(r)
 	}
 
-	for {
+	if err := writeMessage(c.w, req); err != nil {
+		destroy()
+		return nil, err
+	}
+
+	resps := make([]*Response[json.RawMessage], len(reqs))
+
+	for i, ch := range chs {
+		if ch == nil {
+			continue
+		}
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
-		case res := <-ch:
-			if res.Error != nil {
-				return res.Error
+			if pending := destroy(); len(pending) > 0 {
+				go c.sendCancel(pending)
 			}
-
-			return json.Unmarshal(res.Result, result)
+			return nil, ctx.Err()
+		case res, ok := <-ch:
+			if !ok {
+				return nil, c.Err()
+			}
+			resps[i] = &res
 		}
 	}
+
+	return resps, nil
+}
+
+// Call calls a method on the server.
+//
+// The response from the server is unmarshaled into the `result` parameter.
+// If you do not need the response, use `Notify` instead.
+func (c *Client) Call(ctx context.Context, name string, params any, result any) error {
+	return c.caller.call(ctx, name, params, result)
 }
 
 // Notify sends a notification to the server.
@@ -153,14 +405,7 @@ func (c *Client) Call(ctx context.Context, name string, params any, result any)
 // Even if the server replies something, the client will not receive it.
 // If you need the response, use `Call` instead.
 func (c *Client) Notify(ctx context.Context, name string, params any) error {
-	req := Request[any]{
-		Jsonrpc: VersionValue,
-		Method:  name,
-		Params:  &params,
-	}
-
-	_, err := req.WriteTo(c.rw)
-	return err
+	return c.caller.notify(ctx, name, params)
 }
 
 // BatchRequest is a request for `Client.Batch`.
@@ -194,74 +439,5 @@ type BatchResponse struct {
 
 // Batch sends multiple requests to the server at once.
 func (c *Client) Batch(ctx context.Context, reqs []BatchRequest) ([]*BatchResponse, error) {
-	req := messageList[Request[any]]{
-		IsBatch:  true,
-		Messages: make([]Request[any], len(reqs)),
-	}
-
-	var ids []int64
-	var chs []chan Response[json.RawMessage]
-
-	c.mu.Lock()
-	for i, r := range reqs {
-		var id *ID
-		if r.IsNotify {
-			chs = append(chs, nil)
-		} else {
-			id = Int64ID(c.nextID)
-			ids = append(ids, *id.i64)
-			c.nextID++
-			ch := make(chan Response[json.RawMessage], 1)
-			chs = append(chs, ch)
-			c.ch[*id.i64] = ch
-		}
-
-		req.Messages[i] = Request[any]{
-			Jsonrpc: VersionValue,
-			Method:  r.Method,
-			Params:  &r.Params,
-			ID:      id,
-		}
-	}
-	c.mu.Unlock()
-
-	destroy := func() {
-		c.mu.Lock()
-		defer c.mu.Unlock()
-
-		for _, id := range ids {
-			if ch, ok := c.ch[id]; ok {
-				delete(c.ch, id)
-				close(ch)
-			}
-		}
-	}
-
-	if _, err := req.WriteTo(c.rw); err != nil {
-		destroy()
-		return nil, err
-	}
-
-	var resps []*BatchResponse
-
-	for i, ch := range chs {
-		if ch == nil {
-			resps = append(resps, nil)
-			continue
-		}
-		select {
-		case <-ctx.Done():
-			destroy()
-			return nil, ctx.Err()
-		case res := <-ch:
-			resps = append(resps, &BatchResponse{
-				Method: reqs[i].Method,
-				Params: reqs[i].Params,
-				Result: res.Result,
-				Error:  res.Error,
-			})
-		}
-	}
-
-	return resps, nil
+	return c.caller.batch(ctx, reqs)
 }
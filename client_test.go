@@ -4,7 +4,12 @@ import (
 	"context"
 	"errors"
 	"io"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/goccy/go-json"
 	"github.com/google/go-cmp/cmp"
@@ -207,3 +212,342 @@ func TestClient_Batch(t *testing.T) {
 		t.Errorf("unexpected response:\n%s", diff)
 	}
 }
+
+func TestClient_concurrent(t *testing.T) {
+	t.Parallel()
+
+	framings := []struct {
+		Name    string
+		Framing jsonrpc2.Framing
+	}{
+		{"stream", jsonrpc2.StreamFraming{}},
+		{"newline", jsonrpc2.NewlineFraming{}},
+		{"header", jsonrpc2.HeaderFraming{}},
+	}
+
+	for _, f := range framings {
+		t.Run(f.Name, func(t *testing.T) {
+			t.Parallel()
+
+			cli, srv := net.Pipe()
+			defer cli.Close()
+			defer srv.Close()
+
+			var notificationCount uint64
+
+			server := jsonrpc2.NewServer(jsonrpc2.WithFraming(f.Framing), jsonrpc2.WithSequentialDispatch())
+			server.On("add", jsonrpc2.Call(func(ctx context.Context, params []int) (int, error) {
+				sum := 0
+				for _, n := range params {
+					sum += n
+				}
+				return sum, nil
+			}))
+			server.On("count", jsonrpc2.Notify(func(ctx context.Context, n uint64) error {
+				atomic.AddUint64(&notificationCount, n)
+				return nil
+			}))
+			go server.ServeForOne(srv)
+
+			client := jsonrpc2.NewClient(cli, jsonrpc2.WithClientFraming(f.Framing))
+			defer client.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			const N = 300
+
+			var wg sync.WaitGroup
+			for i := 0; i < N; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+
+					var sum int
+					if err := client.Call(ctx, "add", []int{i, i, i}, &sum); err != nil {
+						t.Errorf("%d: failed to call add: %s", i, err)
+					} else if sum != i*3 {
+						t.Errorf("%d: unexpected result of add: %d", i, sum)
+					}
+
+					if err := client.Notify(ctx, "count", 1); err != nil {
+						t.Errorf("%d: failed to notify count: %s", i, err)
+					}
+
+					res, err := client.Batch(ctx, []jsonrpc2.BatchRequest{
+						{Method: "add", Params: []int{i, 1}},
+						{Method: "count", Params: 1, IsNotify: true},
+						{Method: "add", Params: []int{i, 2}},
+					})
+					if err != nil {
+						t.Errorf("%d: failed to call batch: %s", i, err)
+					} else if len(res) != 3 || string(res[0].Result) != strconv.Itoa(i+1) || res[1] != nil || string(res[2].Result) != strconv.Itoa(i+2) {
+						t.Errorf("%d: unexpected result of batch: %v", i, res)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			// Make sure all notifications are processed, because the server handles messages in order.
+			var sum int
+			if err := client.Call(ctx, "add", []int{}, &sum); err != nil {
+				t.Fatalf("failed to call add: %s", err)
+			}
+
+			if n := atomic.LoadUint64(&notificationCount); n != N*2 {
+				t.Errorf("unexpected notification count: %d", n)
+			}
+		})
+	}
+}
+
+func TestClient_concurrentDispatch(t *testing.T) {
+	t.Parallel()
+
+	framings := []struct {
+		Name    string
+		Framing jsonrpc2.Framing
+	}{
+		{"stream", jsonrpc2.StreamFraming{}},
+		{"newline", jsonrpc2.NewlineFraming{}},
+		{"header", jsonrpc2.HeaderFraming{}},
+	}
+
+	for _, f := range framings {
+		t.Run(f.Name, func(t *testing.T) {
+			t.Parallel()
+
+			cli, srv := net.Pipe()
+			defer cli.Close()
+			defer srv.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			const N = 10
+
+			// Every "wait" call blocks until all of them have arrived, so they can only finish if the server dispatches them concurrently.
+			var arrived, notified sync.WaitGroup
+			arrived.Add(N)
+			notified.Add(N)
+			allArrived := make(chan struct{})
+			go func() {
+				arrived.Wait()
+				close(allArrived)
+			}()
+
+			server := jsonrpc2.NewServer(jsonrpc2.WithFraming(f.Framing))
+			server.On("wait", jsonrpc2.Call(func(ctx context.Context, n int) (int, error) {
+				arrived.Done()
+				select {
+				case <-allArrived:
+					return n, nil
+				case <-ctx.Done():
+					return 0, ctx.Err()
+				}
+			}))
+			server.On("count", jsonrpc2.Notify(func(ctx context.Context, _ any) error {
+				notified.Done()
+				return nil
+			}))
+			go server.ServeForOne(srv)
+
+			client := jsonrpc2.NewClient(cli, jsonrpc2.WithClientFraming(f.Framing))
+			defer client.Close()
+
+			var wg sync.WaitGroup
+			for i := 0; i < N; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+
+					var n int
+					if err := client.Call(ctx, "wait", i, &n); err != nil {
+						t.Errorf("%d: failed to call wait: %s", i, err)
+					} else if n != i {
+						t.Errorf("%d: unexpected result of wait: %d", i, n)
+					}
+
+					if err := client.Notify(ctx, "count", nil); err != nil {
+						t.Errorf("%d: failed to notify count: %s", i, err)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			// Notifications have no response, so wait for the handlers themselves.
+			done := make(chan struct{})
+			go func() {
+				notified.Wait()
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-ctx.Done():
+				t.Fatalf("notifications are not handled")
+			}
+		})
+	}
+}
+
+func TestClient_connectionLost(t *testing.T) {
+	t.Parallel()
+
+	cli, srv := BiDirectionalPipe(t)
+	client := jsonrpc2.NewClient(cli)
+	defer client.Close()
+
+	if err := client.Err(); err != nil {
+		t.Fatalf("unexpected error before the connection lost: %s", err)
+	}
+
+	errCh := make(chan error)
+	go func() {
+		var result int
+		errCh <- client.Call(context.Background(), "add", []int{1, 2}, &result)
+	}()
+
+	// Wait for the request, and then close the connection without replying.
+	if _, err := (jsonrpc2.StreamFraming{}).NewReader(srv).ReadFrame(); err != nil {
+		t.Fatalf("failed to read request: %s", err)
+	}
+	srv.Close()
+
+	var connErr *jsonrpc2.ConnectionError
+	select {
+	case err := <-errCh:
+		if !errors.As(err, &connErr) || !errors.Is(err, io.EOF) {
+			t.Errorf("unexpected error of pending call: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("pending call did not fail")
+	}
+
+	select {
+	case <-client.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatalf("client is not done")
+	}
+
+	if err := client.Err(); !errors.As(err, &connErr) {
+		t.Errorf("unexpected error after the connection lost: %v", err)
+	}
+
+	var result int
+	if err := client.Call(context.Background(), "add", []int{1, 2}, &result); !errors.As(err, &connErr) {
+		t.Errorf("unexpected error of new call: %v", err)
+	}
+	if err := client.Notify(context.Background(), "add", []int{1, 2}); !errors.As(err, &connErr) {
+		t.Errorf("unexpected error of new notification: %v", err)
+	}
+
+	// Close does not overwrite the reason.
+	client.Close()
+	if err := client.Err(); !errors.As(err, &connErr) {
+		t.Errorf("unexpected error after close: %v", err)
+	}
+}
+
+func TestClient_Close(t *testing.T) {
+	t.Parallel()
+
+	cli, srv := BiDirectionalPipe(t)
+	defer srv.Close()
+
+	client := jsonrpc2.NewClient(cli)
+
+	errCh := make(chan error)
+	go func() {
+		var result int
+		errCh <- client.Call(context.Background(), "add", []int{1, 2}, &result)
+	}()
+
+	if _, err := (jsonrpc2.StreamFraming{}).NewReader(srv).ReadFrame(); err != nil {
+		t.Fatalf("failed to read request: %s", err)
+	}
+	client.Close()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, jsonrpc2.ErrClientClosed) {
+			t.Errorf("unexpected error of pending call: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("pending call did not fail")
+	}
+
+	<-client.Done()
+
+	if err := client.Err(); !errors.Is(err, jsonrpc2.ErrClientClosed) {
+		t.Errorf("unexpected error after close: %v", err)
+	}
+
+	var result int
+	if err := client.Call(context.Background(), "add", []int{1, 2}, &result); !errors.Is(err, jsonrpc2.ErrClientClosed) {
+		t.Errorf("unexpected error of new call: %v", err)
+	}
+}
+
+func TestClient_idGenerator(t *testing.T) {
+	t.Parallel()
+
+	server := jsonrpc2.NewServer()
+	server.On("add", jsonrpc2.Call(func(ctx context.Context, xs []int) (int, error) {
+		return xs[0] + xs[1], nil
+	}))
+
+	generators := []struct {
+		Name      string
+		Generator jsonrpc2.IDGenerator
+	}{
+		{"sequential", jsonrpc2.SequentialIDGenerator()},
+		{"prefix", jsonrpc2.PrefixIDGenerator("req-")},
+		{"uuidv4", jsonrpc2.UUIDv4Generator()},
+		{"uuidv7", jsonrpc2.UUIDv7Generator()},
+	}
+
+	for _, g := range generators {
+		t.Run(g.Name, func(t *testing.T) {
+			t.Parallel()
+
+			cli, srv := BiDirectionalPipe(t)
+			defer cli.Close()
+			go server.ServeForOne(srv)
+
+			var ids []*jsonrpc2.ID
+			client := jsonrpc2.NewClient(
+				cli,
+				jsonrpc2.WithIDGenerator(g.Generator),
+				jsonrpc2.WithInterceptor(func(ctx context.Context, req *jsonrpc2.Request[any], next jsonrpc2.Invoker) (*jsonrpc2.Response[json.RawMessage], error) {
+					ids = append(ids, req.ID)
+					return next(ctx, req)
+				}),
+			)
+			defer client.Close()
+
+			var result int
+			if err := client.Call(context.Background(), "add", []int{1, 2}, &result); err != nil {
+				t.Fatalf("failed to call: %s", err)
+			} else if result != 3 {
+				t.Errorf("unexpected result: %d", result)
+			}
+
+			res, err := client.Batch(context.Background(), []jsonrpc2.BatchRequest{
+				{Method: "add", Params: []int{3, 4}},
+				{Method: "add", Params: []int{5, 6}},
+			})
+			if err != nil {
+				t.Fatalf("failed to call batch: %s", err)
+			}
+			for i, want := range []string{"7", "11"} {
+				if string(res[i].Result) != want {
+					t.Errorf("unexpected result of batch[%d]: %s", i, res[i].Result)
+				}
+			}
+
+			if len(ids) != 1 || ids[0].Raw() == nil {
+				t.Errorf("unexpected IDs: %v", ids)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strings"
+
+	"github.com/macrat/go-jsonrpc2"
+)
+
+// GenerateGo generates a typed Go client from the OpenRPC document.
+func GenerateGo(doc jsonrpc2.OpenRPCDocument, opts Options) ([]byte, error) {
+	g := &goGenerator{imports: map[string]bool{"context": true}}
+
+	var body bytes.Buffer
+
+	for _, name := range schemaNames(doc) {
+		s := doc.Components.Schemas[name]
+		fmt.Fprintf(&body, "// %s is the %q schema.\n", pascalCase(name, true), name)
+		fmt.Fprintf(&body, "type %s %s\n\n", pascalCase(name, true), g.typeOf(s))
+	}
+
+	fmt.Fprintf(&body, "// Caller is the interface that %s uses to send requests.\n", opts.Client)
+	fmt.Fprintf(&body, "// *jsonrpc2.Client, *jsonrpc2.HTTPClient, *jsonrpc2.Conn, and *jsonrpc2.ReconnectingClient implement it.\n")
+	fmt.Fprintf(&body, "type Caller interface {\n")
+	fmt.Fprintf(&body, "Call(ctx context.Context, name string, params any, result any) error\n")
+	fmt.Fprintf(&body, "Notify(ctx context.Context, name string, params any) error\n")
+	fmt.Fprintf(&body, "}\n\n")
+
+	fmt.Fprintf(&body, "// %s is a typed client for %s.\n", opts.Client, doc.Info.Title)
+	fmt.Fprintf(&body, "type %s struct {\ncaller Caller\n}\n\n", opts.Client)
+	fmt.Fprintf(&body, "// New%s creates a new %s.\n", opts.Client, opts.Client)
+	fmt.Fprintf(&body, "func New%s(caller Caller) *%s {\nreturn &%s{caller: caller}\n}\n", opts.Client, opts.Client, opts.Client)
+
+	for _, m := range doc.Methods {
+		g.method(&body, opts, m)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by jsonrpc2-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", opts.Package)
+	out.WriteString("import (\n")
+	for _, imp := range []string{"context", "time"} {
+		if g.imports[imp] {
+			fmt.Fprintf(&out, "%q\n", imp)
+		}
+	}
+	out.WriteString(")\n\n")
+	out.Write(body.Bytes())
+
+	code, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return code, nil
+}
+
+type goGenerator struct {
+	imports map[string]bool
+}
+
+func (g *goGenerator) method(w *bytes.Buffer, opts Options, m jsonrpc2.OpenRPCMethod) {
+	name := pascalCase(m.Name, true)
+
+	args := []string{"ctx context.Context"}
+	var names []string
+	for _, p := range m.Params {
+		n := goParamName(p.Name)
+		if m.RawParams {
+			n = "params"
+		}
+		names = append(names, n)
+		args = append(args, n+" "+g.typeOf(p.Schema))
+	}
+
+	var params string
+	switch {
+	case m.RawParams:
+		params = "params"
+	case len(m.Params) == 0:
+		params = "nil"
+	case m.ParamStructure == "by-name":
+		var kvs []string
+		for i, p := range m.Params {
+			kvs = append(kvs, fmt.Sprintf("%q: %s", p.Name, names[i]))
+		}
+		params = "map[string]any{" + strings.Join(kvs, ", ") + "}"
+	default:
+		params = "[]any{" + strings.Join(names, ", ") + "}"
+	}
+
+	fmt.Fprintf(w, "\n")
+	writeGoComment(w, name, m)
+
+	if m.Notification {
+		fmt.Fprintf(w, "func (c *%s) %s(%s) error {\n", opts.Client, name, strings.Join(args, ", "))
+		fmt.Fprintf(w, "return c.caller.Notify(ctx, %q, %s)\n}\n", m.Name, params)
+		return
+	}
+
+	// The result is unknown if it is omitted, as OpenRPC allows.
+	var schema *jsonrpc2.JSONSchema
+	if m.Result != nil {
+		schema = m.Result.Schema
+	}
+	result := g.typeOf(schema)
+	fmt.Fprintf(w, "func (c *%s) %s(%s) (%s, error) {\n", opts.Client, name, strings.Join(args, ", "), result)
+	fmt.Fprintf(w, "var result %s\n", result)
+	fmt.Fprintf(w, "err := c.caller.Call(ctx, %q, %s, &result)\n", m.Name, params)
+	fmt.Fprintf(w, "return result, err\n}\n")
+}
+
+func writeGoComment(w *bytes.Buffer, name string, m jsonrpc2.OpenRPCMethod) {
+	fmt.Fprintf(w, "// %s calls %q method.\n", name, m.Name)
+
+	summary := m.Summary
+	if summary != "" && !strings.HasSuffix(summary, ".") {
+		// A line without period is treated as a heading by gofmt.
+		summary += "."
+	}
+
+	for _, text := range []string{summary, m.Description} {
+		if text == "" {
+			continue
+		}
+		fmt.Fprintf(w, "//\n")
+		for _, line := range strings.Split(text, "\n") {
+			fmt.Fprintf(w, "// %s\n", line)
+		}
+	}
+
+	if len(m.Errors) > 0 {
+		fmt.Fprintf(w, "//\n// Possible errors:\n")
+		for _, e := range m.Errors {
+			fmt.Fprintf(w, "//   - %d: %s\n", e.Code, e.Message)
+		}
+	}
+}
+
+func goParamName(name string) string {
+	n := camelCase(name, true)
+	if token.IsKeyword(n) || n == "ctx" || n == "c" || n == "result" || n == "err" {
+		n += "_"
+	}
+	return n
+}
+
+// typeOf returns the Go type for the schema.
+func (g *goGenerator) typeOf(s *jsonrpc2.JSONSchema) string {
+	if s == nil {
+		return "any"
+	}
+	if s.Ref != "" {
+		return pascalCase(refName(s.Ref), true)
+	}
+
+	switch s.Type {
+	case "boolean":
+		return "bool"
+	case "integer":
+		return "int"
+	case "number":
+		return "float64"
+	case "string":
+		switch s.Format {
+		case "date-time":
+			g.imports["time"] = true
+			return "time.Time"
+		case "byte":
+			return "[]byte"
+		}
+		return "string"
+	case "array":
+		if s.MinItems != nil && s.MaxItems != nil && *s.MinItems == *s.MaxItems {
+			return fmt.Sprintf("[%d]%s", *s.MinItems, g.typeOf(s.Items))
+		}
+		return "[]" + g.typeOf(s.Items)
+	case "object":
+		if s.Properties == nil {
+			return "map[string]" + g.typeOf(s.AdditionalProperties)
+		}
+		return g.structOf(s)
+	default:
+		return "any"
+	}
+}
+
+func (g *goGenerator) structOf(s *jsonrpc2.JSONSchema) string {
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	for _, name := range propertyNames(s) {
+		prop := s.Properties[name]
+
+		typ := g.typeOf(prop)
+		tag := name
+		if !isRequired(s, name) {
+			tag += ",omitempty"
+		}
+		// Use pointers for references, because the referenced type can be recursive.
+		if prop.Ref != "" {
+			typ = "*" + typ
+		}
+
+		fmt.Fprintf(&b, "%s %s `json:%q`\n", pascalCase(name, true), typ, tag)
+	}
+	b.WriteString("}")
+	return b.String()
+}
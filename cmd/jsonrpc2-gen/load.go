@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/goccy/go-json"
+	"github.com/macrat/go-jsonrpc2"
+)
+
+// loaderTemplate is a program that prints the OpenRPC document of a server in a Go package.
+//
+// The server is read as `any` so that the same program works for both of a variable and a constructor.
+var loaderTemplate = template.Must(template.New("loader").Parse(`package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/macrat/go-jsonrpc2"
+	target {{ printf "%q" .Path }}
+)
+
+func main() {
+	var server *jsonrpc2.Server
+	switch v := any(target.{{ .Name }}).(type) {
+	case *jsonrpc2.Server:
+		server = v
+	case func() *jsonrpc2.Server:
+		server = v()
+	default:
+		fmt.Fprintf(os.Stderr, "%s.%s is %T, not *jsonrpc2.Server or func() *jsonrpc2.Server\n", {{ printf "%q" .Path }}, {{ printf "%q" .Name }}, v)
+		os.Exit(1)
+	}
+	if server == nil {
+		fmt.Fprintf(os.Stderr, "%s.%s is nil\n", {{ printf "%q" .Path }}, {{ printf "%q" .Name }})
+		os.Exit(1)
+	}
+
+	if err := json.NewEncoder(os.Stdout).Encode(server.OpenRPC()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+`))
+
+// splitServerRef splits a reference like "example.com/api.NewServer" into the import path and the name.
+func splitServerRef(ref string) (path, name string, err error) {
+	i := strings.LastIndex(ref, ".")
+	if i <= strings.LastIndex(ref, "/") || i == len(ref)-1 {
+		return "", "", fmt.Errorf("invalid server reference: %q: it should be like \"example.com/api.NewServer\"", ref)
+	}
+	return ref[:i], ref[i+1:], nil
+}
+
+// loadServer reads the OpenRPC document of a server that is defined in a Go package.
+//
+// The `ref` is an import path and a name, like "example.com/api.NewServer".
+// The name should be a variable of `*jsonrpc2.Server` or a function that returns it without arguments.
+//
+// The package is built and run by the go command in the current directory,
+// so it has to be importable from the module in the current directory.
+// The types of params and results are described by `Server.OpenRPC`, the same as the running server does.
+func loadServer(ref string) (jsonrpc2.OpenRPCDocument, error) {
+	var doc jsonrpc2.OpenRPCDocument
+
+	path, name, err := splitServerRef(ref)
+	if err != nil {
+		return doc, err
+	}
+
+	dir, err := os.MkdirTemp("", "jsonrpc2-gen-")
+	if err != nil {
+		return doc, err
+	}
+	defer os.RemoveAll(dir)
+
+	var src bytes.Buffer
+	if err := loaderTemplate.Execute(&src, struct{ Path, Name string }{path, name}); err != nil {
+		return doc, err
+	}
+	main := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(main, src.Bytes(), 0o644); err != nil {
+		return doc, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("go", "run", main)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return doc, fmt.Errorf("failed to load %s: %w\n%s", ref, err, strings.TrimSpace(stderr.String()))
+	}
+
+	if err := json.Unmarshal(stdout.Bytes(), &doc); err != nil {
+		return doc, fmt.Errorf("failed to read OpenRPC document of %s: %w", ref, err)
+	}
+	return doc, nil
+}
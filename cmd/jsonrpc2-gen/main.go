@@ -0,0 +1,128 @@
+// jsonrpc2-gen generates typed JSON-RPC 2.0 clients from an OpenRPC document.
+//
+// Usage:
+//
+//	jsonrpc2-gen [flags] [openrpc.json]
+//
+// The document is read from the file, the standard input if the file is "-" or omitted,
+// the running server specified by -url through the "rpc.discover" method,
+// or the Go package specified by -server.
+//
+// The -server flag takes an import path and a name, like "example.com/api.NewServer".
+// The name should be a variable of *jsonrpc2.Server or a function that returns it without arguments.
+// This command builds a small program that imports the package and prints the result of `Server.OpenRPC`,
+// so the package has to be importable from the module in the current directory.
+//
+// Flags:
+//
+//	-lang string     language of the generated client, "go" or "ts" (default "go")
+//	-package string  package name of the generated Go client (default "client")
+//	-client string   type name of the generated client (default "Client")
+//	-url string      read the document from the server at the URL over HTTP
+//	-server string   read the document from the server in the Go package, like "example.com/api.NewServer"
+//	-o string        output file (default: standard output)
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/goccy/go-json"
+	"github.com/macrat/go-jsonrpc2"
+)
+
+// Options is the options for generators.
+type Options struct {
+	// Package is the package name of the generated Go client.
+	Package string
+
+	// Client is the type name of the generated client.
+	Client string
+}
+
+func main() {
+	lang := flag.String("lang", "go", `language of the generated client, "go" or "ts"`)
+	pkg := flag.String("package", "client", "package name of the generated Go client")
+	client := flag.String("client", "Client", "type name of the generated client")
+	url := flag.String("url", "", "read the document from the server at the URL over HTTP")
+	server := flag.String("server", "", `read the document from the server in the Go package, like "example.com/api.NewServer"`)
+	out := flag.String("o", "", "output file (default: standard output)")
+	flag.Parse()
+
+	src := Source{URL: *url, Server: *server, File: flag.Arg(0)}
+	if err := run(*lang, Options{Package: *pkg, Client: *client}, src, *out); err != nil {
+		fmt.Fprintf(os.Stderr, "jsonrpc2-gen: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// Source specifies where to read the OpenRPC document from.
+// Only one of the fields should be set. If all of them are empty, the document is read from the standard input.
+type Source struct {
+	// URL is the URL of the running server.
+	URL string
+
+	// Server is the import path and the name of the server in a Go package, like "example.com/api.NewServer".
+	Server string
+
+	// File is the path to the document. "-" means the standard input.
+	File string
+}
+
+func run(lang string, opts Options, src Source, out string) error {
+	doc, err := readDocument(src)
+	if err != nil {
+		return err
+	}
+
+	var code []byte
+	switch lang {
+	case "go":
+		code, err = GenerateGo(doc, opts)
+	case "ts":
+		code, err = GenerateTypeScript(doc, opts)
+	default:
+		return fmt.Errorf("unsupported language: %q", lang)
+	}
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(out, code, 0o644)
+}
+
+func readDocument(src Source) (jsonrpc2.OpenRPCDocument, error) {
+	var doc jsonrpc2.OpenRPCDocument
+
+	switch {
+	case src.URL != "" && src.Server != "":
+		return doc, fmt.Errorf("-url and -server cannot be used together")
+	case src.URL != "":
+		err := jsonrpc2.NewHTTPClient(src.URL, nil).Call(context.Background(), jsonrpc2.DiscoverMethod, nil, &doc)
+		return doc, err
+	case src.Server != "":
+		return loadServer(src.Server)
+	}
+
+	var r io.Reader = os.Stdin
+	if src.File != "" && src.File != "-" {
+		f, err := os.Open(src.File)
+		if err != nil {
+			return doc, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return doc, fmt.Errorf("failed to read OpenRPC document: %w", err)
+	}
+	return doc, nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/macrat/go-jsonrpc2"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile(filepath.Join("testdata", "openrpc.json"))
+	if err != nil {
+		t.Fatalf("failed to read input: %s", err)
+	}
+
+	var doc jsonrpc2.OpenRPCDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("failed to parse input: %s", err)
+	}
+
+	tests := []struct {
+		Golden   string
+		Generate func(jsonrpc2.OpenRPCDocument, Options) ([]byte, error)
+	}{
+		{"client.go.golden", GenerateGo},
+		{"client.ts.golden", GenerateTypeScript},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Golden, func(t *testing.T) {
+			got, err := tt.Generate(doc, Options{Package: "example", Client: "ExampleClient"})
+			if err != nil {
+				t.Fatalf("failed to generate: %s", err)
+			}
+
+			path := filepath.Join("testdata", tt.Golden)
+			if *update {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatalf("failed to update golden file: %s", err)
+				}
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read golden file: %s", err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("generated code does not match %s. Please run `go test -update` if it is expected.\n%s", path, got)
+			}
+		})
+	}
+}
+
+func TestGenerateGo_typecheck(t *testing.T) {
+	t.Parallel()
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filepath.Join("testdata", "client.go.golden"), nil, 0)
+	if err != nil {
+		t.Fatalf("failed to parse generated code: %s", err)
+	}
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("example", fset, []*ast.File{f}, nil); err != nil {
+		t.Errorf("generated code does not compile: %s", err)
+	}
+}
+
+func TestRun_url(t *testing.T) {
+	t.Parallel()
+
+	server := jsonrpc2.NewServer()
+	server.On("sum", jsonrpc2.Call(func(ctx context.Context, xs []int) (int, error) {
+		return 0, nil
+	}))
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	out := filepath.Join(t.TempDir(), "client.go")
+	if err := run("go", Options{Package: "api", Client: "MathClient"}, Source{URL: ts.URL}, out); err != nil {
+		t.Fatalf("failed to generate: %s", err)
+	}
+
+	code, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("failed to read output: %s", err)
+	}
+
+	want := "func (c *MathClient) Sum(ctx context.Context, params []int) (int, error) {"
+	if !strings.Contains(string(code), want) {
+		t.Errorf("generated code does not contain %q:\n%s", want, code)
+	}
+}
+
+func TestRun_server(t *testing.T) {
+	t.Parallel()
+
+	const pkg = "github.com/macrat/go-jsonrpc2/cmd/jsonrpc2-gen/testdata/server"
+
+	tests := []struct {
+		Server string
+		Error  string
+	}{
+		{pkg + ".NewServer", ""},
+		{pkg + ".Server", ""},
+		{pkg + ".Name", "not *jsonrpc2.Server"},
+		{pkg + ".Unknown", "undefined"},
+		{pkg, "invalid server reference"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Server[strings.LastIndex(tt.Server, "/")+1:], func(t *testing.T) {
+			t.Parallel()
+
+			out := filepath.Join(t.TempDir(), "client.go")
+			err := run("go", Options{Package: "api", Client: "MathClient"}, Source{Server: tt.Server}, out)
+			if tt.Error != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.Error) {
+					t.Errorf("expected an error contains %q but got %v", tt.Error, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to generate: %s", err)
+			}
+
+			code, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatalf("failed to read output: %s", err)
+			}
+
+			want := "func (c *MathClient) Sum(ctx context.Context, params []int) (int, error) {"
+			if !strings.Contains(string(code), want) {
+				t.Errorf("generated code does not contain %q:\n%s", want, code)
+			}
+		})
+	}
+}
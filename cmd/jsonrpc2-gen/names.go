@@ -0,0 +1,125 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/macrat/go-jsonrpc2"
+)
+
+// initialisms is a set of words that are written in upper case in Go identifiers.
+var initialisms = map[string]bool{
+	"API":  true,
+	"HTTP": true,
+	"ID":   true,
+	"JSON": true,
+	"RPC":  true,
+	"URI":  true,
+	"URL":  true,
+	"UUID": true,
+}
+
+// splitWords splits a name like "getUser", "get_user", or "math.add" into words.
+func splitWords(name string) []string {
+	var words []string
+	var cur []rune
+
+	flush := func() {
+		if len(cur) > 0 {
+			words = append(words, string(cur))
+			cur = nil
+		}
+	}
+
+	rs := []rune(name)
+	for i, r := range rs {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && len(cur) > 0 && (unicode.IsLower(cur[len(cur)-1]) || (i+1 < len(rs) && unicode.IsLower(rs[i+1]))):
+			flush()
+			cur = append(cur, r)
+		default:
+			cur = append(cur, r)
+		}
+	}
+	flush()
+
+	return words
+}
+
+// pascalCase converts a name into PascalCase, respecting initialisms for Go.
+func pascalCase(name string, useInitialisms bool) string {
+	var b strings.Builder
+	for _, w := range splitWords(name) {
+		if up := strings.ToUpper(w); useInitialisms && initialisms[up] {
+			b.WriteString(up)
+		} else {
+			rs := []rune(w)
+			b.WriteRune(unicode.ToUpper(rs[0]))
+			b.WriteString(string(rs[1:]))
+		}
+	}
+
+	s := b.String()
+	if s == "" || unicode.IsDigit([]rune(s)[0]) {
+		s = "X" + s
+	}
+	return s
+}
+
+// camelCase converts a name into camelCase.
+func camelCase(name string, useInitialisms bool) string {
+	words := splitWords(name)
+	if len(words) == 0 {
+		return "x"
+	}
+
+	first := strings.ToLower(words[0])
+	if unicode.IsDigit([]rune(first)[0]) {
+		first = "x" + first
+	}
+	if len(words) == 1 {
+		return first
+	}
+	return first + pascalCase(strings.Join(words[1:], "_"), useInitialisms)
+}
+
+// schemaNames returns the names of component schemas in sorted order.
+func schemaNames(doc jsonrpc2.OpenRPCDocument) []string {
+	if doc.Components == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(doc.Components.Schemas))
+	for name := range doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// propertyNames returns the names of properties in sorted order.
+func propertyNames(s *jsonrpc2.JSONSchema) []string {
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// refName returns the name of the referenced schema.
+func refName(ref string) string {
+	return strings.TrimPrefix(ref, "#/components/schemas/")
+}
+
+func isRequired(s *jsonrpc2.JSONSchema, name string) bool {
+	for _, r := range s.Required {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+// Package server is a Go package that defines a server for tests of the -server flag.
+package server
+
+import (
+	"context"
+
+	"github.com/macrat/go-jsonrpc2"
+)
+
+// NewServer creates the server.
+func NewServer() *jsonrpc2.Server {
+	server := jsonrpc2.NewServer()
+	server.On("sum", jsonrpc2.Call(func(ctx context.Context, xs []int) (int, error) {
+		sum := 0
+		for _, x := range xs {
+			sum += x
+		}
+		return sum, nil
+	}))
+	return server
+}
+
+// Server is the server as a variable.
+var Server = NewServer()
+
+// Name is not a server.
+const Name = "server"
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/macrat/go-jsonrpc2"
+)
+
+// tsRuntime is the transport part of the generated TypeScript client.
+const tsRuntime = `/** Transport sends requests to the server. */
+export interface Transport {
+  call(method: string, params: unknown): Promise<unknown>;
+  notify(method: string, params: unknown): Promise<void>;
+}
+
+/** RPCError is an error that the server responded. */
+export class RPCError extends Error {
+  constructor(
+    readonly code: number,
+    message: string,
+    readonly data?: unknown,
+  ) {
+    super(message);
+  }
+}
+
+/** httpTransport creates a Transport that sends requests to the URL with fetch. */
+export function httpTransport(url: string): Transport {
+  let nextId = 0;
+
+  const post = async (body: unknown): Promise<Response> => {
+    return await fetch(url, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(body),
+    });
+  };
+
+  return {
+    async call(method, params) {
+      const res = await post({ jsonrpc: "2.0", method, params, id: nextId++ });
+      const body = await res.json();
+      if (body.error) {
+        throw new RPCError(body.error.code, body.error.message, body.error.data);
+      }
+      return body.result;
+    },
+    async notify(method, params) {
+      await post({ jsonrpc: "2.0", method, params });
+    },
+  };
+}
+
+function trimUndefined(params: unknown[]): unknown[] {
+  let n = params.length;
+  while (n > 0 && params[n - 1] === undefined) {
+    n--;
+  }
+  return params.slice(0, n);
+}
+`
+
+// GenerateTypeScript generates a typed TypeScript client from the OpenRPC document.
+func GenerateTypeScript(doc jsonrpc2.OpenRPCDocument, opts Options) ([]byte, error) {
+	var w bytes.Buffer
+
+	fmt.Fprintf(&w, "// Code generated by jsonrpc2-gen. DO NOT EDIT.\n\n")
+	w.WriteString(tsRuntime)
+
+	for _, name := range schemaNames(doc) {
+		s := doc.Components.Schemas[name]
+		fmt.Fprintf(&w, "\n/** %s is the %q schema. */\n", pascalCase(name, false), name)
+		if s.Type == "object" && s.Properties != nil {
+			fmt.Fprintf(&w, "export interface %s %s\n", pascalCase(name, false), tsObject(s, ""))
+		} else {
+			fmt.Fprintf(&w, "export type %s = %s;\n", pascalCase(name, false), tsTypeOf(s, ""))
+		}
+	}
+
+	fmt.Fprintf(&w, "\n/** %s is a typed client for %s. */\n", opts.Client, doc.Info.Title)
+	fmt.Fprintf(&w, "export class %s {\n", opts.Client)
+	fmt.Fprintf(&w, "  constructor(private readonly transport: Transport) {}\n")
+
+	for _, m := range doc.Methods {
+		tsMethod(&w, m)
+	}
+
+	fmt.Fprintf(&w, "}\n")
+
+	return w.Bytes(), nil
+}
+
+func tsMethod(w *bytes.Buffer, m jsonrpc2.OpenRPCMethod) {
+	// Only trailing optional params can be omitted.
+	optionalFrom := len(m.Params)
+	for i := len(m.Params) - 1; i >= 0 && !m.Params[i].Required; i-- {
+		optionalFrom = i
+	}
+
+	var args, names []string
+	for i, p := range m.Params {
+		n := tsParamName(p.Name)
+		if m.RawParams {
+			n = "params"
+		}
+		names = append(names, n)
+
+		opt := ""
+		if i >= optionalFrom {
+			opt = "?"
+		}
+		args = append(args, fmt.Sprintf("%s%s: %s", n, opt, tsTypeOf(p.Schema, "    ")))
+	}
+
+	var params string
+	switch {
+	case m.RawParams:
+		params = "params"
+	case len(m.Params) == 0:
+		params = "null"
+	case m.ParamStructure == "by-name":
+		var kvs []string
+		for i, p := range m.Params {
+			kvs = append(kvs, fmt.Sprintf("%q: %s", p.Name, names[i]))
+		}
+		params = "{ " + strings.Join(kvs, ", ") + " }"
+	default:
+		params = "trimUndefined([" + strings.Join(names, ", ") + "])"
+	}
+
+	fmt.Fprintf(w, "\n")
+	writeTSComment(w, m)
+
+	name := camelCase(m.Name, false)
+	if m.Notification {
+		fmt.Fprintf(w, "  async %s(%s): Promise<void> {\n", name, strings.Join(args, ", "))
+		fmt.Fprintf(w, "    await this.transport.notify(%q, %s);\n", m.Name, params)
+		fmt.Fprintf(w, "  }\n")
+		return
+	}
+
+	// The result is unknown if it is omitted, as OpenRPC allows.
+	var schema *jsonrpc2.JSONSchema
+	if m.Result != nil {
+		schema = m.Result.Schema
+	}
+	result := tsTypeOf(schema, "    ")
+	fmt.Fprintf(w, "  async %s(%s): Promise<%s> {\n", name, strings.Join(args, ", "), result)
+	fmt.Fprintf(w, "    return (await this.transport.call(%q, %s)) as %s;\n", m.Name, params, result)
+	fmt.Fprintf(w, "  }\n")
+}
+
+func writeTSComment(w *bytes.Buffer, m jsonrpc2.OpenRPCMethod) {
+	var lines []string
+	if m.Summary != "" {
+		lines = append(lines, m.Summary)
+	} else {
+		lines = append(lines, fmt.Sprintf("Calls %q method.", m.Name))
+	}
+	if m.Description != "" {
+		lines = append(lines, "")
+		lines = append(lines, strings.Split(m.Description, "\n")...)
+	}
+	for _, e := range m.Errors {
+		lines = append(lines, fmt.Sprintf("@throws {RPCError} %d: %s", e.Code, e.Message))
+	}
+
+	fmt.Fprintf(w, "  /**\n")
+	for _, line := range lines {
+		if line == "" {
+			fmt.Fprintf(w, "   *\n")
+		} else {
+			fmt.Fprintf(w, "   * %s\n", line)
+		}
+	}
+	fmt.Fprintf(w, "   */\n")
+}
+
+func tsParamName(name string) string {
+	n := camelCase(name, false)
+	switch n {
+	case "params", "this", "class", "function", "default", "delete", "new", "var", "let", "const", "in", "typeof", "void":
+		n += "_"
+	}
+	return n
+}
+
+// tsPropertyName quotes the name if it is not a valid identifier.
+func tsPropertyName(name string) string {
+	for i, r := range name {
+		if !(r == '_' || r == '$' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))) {
+			return strconv.Quote(name)
+		}
+	}
+	if name == "" {
+		return `""`
+	}
+	return name
+}
+
+// tsTypeOf returns the TypeScript type for the schema.
+func tsTypeOf(s *jsonrpc2.JSONSchema, indent string) string {
+	if s == nil {
+		return "unknown"
+	}
+	if s.Ref != "" {
+		return pascalCase(refName(s.Ref), false)
+	}
+
+	switch s.Type {
+	case "boolean":
+		return "boolean"
+	case "integer", "number":
+		return "number"
+	case "string":
+		return "string"
+	case "array":
+		item := tsTypeOf(s.Items, indent)
+		if strings.ContainsAny(item, " |") {
+			item = "(" + item + ")"
+		}
+		return item + "[]"
+	case "object":
+		if s.Properties == nil {
+			return "Record<string, " + tsTypeOf(s.AdditionalProperties, indent) + ">"
+		}
+		return tsObject(s, indent)
+	default:
+		return "unknown"
+	}
+}
+
+func tsObject(s *jsonrpc2.JSONSchema, indent string) string {
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, name := range propertyNames(s) {
+		opt := ""
+		if !isRequired(s, name) {
+			opt = "?"
+		}
+		fmt.Fprintf(&b, "%s  %s%s: %s;\n", indent, tsPropertyName(name), opt, tsTypeOf(s.Properties[name], indent+"  "))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
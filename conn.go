@@ -0,0 +1,271 @@
+package jsonrpc2
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/goccy/go-json"
+)
+
+// Conn is a bidirectional JSON-RPC 2.0 connection.
+//
+// Conn can call methods on the peer like `Client`, and can also serve methods for the peer like `Server`.
+// This is useful for protocols that both sides send requests, such as Language Server Protocol.
+type Conn struct {
+	client *Client
+	server *Server
+	w      FrameWriter
+}
+
+// NewConn creates a new bidirectional JSON-RPC 2.0 connection.
+//
+// The `rw` parameter is the read-writer to communicate with the peer.
+// The `handler` parameter is used for requests from the peer that are not registered by `Conn.On`.
+// It can be nil, and in that case, the connection replies `ErrMethodNotFound` to such requests.
+//
+// The `opts` parameter is the same as `NewClient`.
+// Use `WithServerOptions` to configure how requests from the peer are handled.
+//
+// This function starts a goroutine to read messages from the peer.
+// Please make sure to call `Close` to stop the goroutine when you are done.
+func NewConn(rw io.ReadWriter, handler Handler, opts ...ClientOption) *Conn {
+	if rw == nil {
+		panic("jsonrpc2: rw for jsonrpc2.NewConn is nil")
+	}
+
+	conf := newClientConfig(opts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	client := newClient(conf, newFrameReader(conf.framing, rw), newFrameWriter(conf.framing, rw), cancel)
+
+	server := NewServer(conf.serverOptions...)
+	server.fallback = handler
+
+	conn := &Conn{
+		client: client,
+		server: server,
+		// Share the writer with the client, because both requests and responses are written to the same stream.
+		w: client.w,
+	}
+
+	go conn.run(withInflightRequests(ctx))
+
+	return conn
+}
+
+// WithServerOptions specifies the server options for requests from the peer of `NewConn`.
+// Options about streams such as `WithFraming` are ignored, because `Conn` reads and writes through the client side.
+//
+// This option is used only by `NewConn`.
+func WithServerOptions(opts ...ServerOption) ClientOption {
+	return func(c *clientConfig) {
+		c.serverOptions = append(c.serverOptions, opts...)
+	}
+}
+
+// On registers a new handler for a method that the peer can call.
+//
+// Handlers registered by this method take precedence over the handler passed to `NewConn`.
+func (c *Conn) On(name string, h Handler) {
+	c.server.On(name, h)
+}
+
+// isResponse reports whether a message in a frame is a response.
+// Objects that have "result" or "error" and do not have "method" are responses, and other messages are requests.
+func isResponse(data json.RawMessage) bool {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return false
+	}
+	_, hasMethod := m["method"]
+	_, hasResult := m["result"]
+	_, hasError := m["error"]
+	return !hasMethod && (hasResult || hasError)
+}
+
+// splitMessages splits a frame from the peer into requests and responses.
+//
+// The requests are returned as a frame for decodeRequests, or nil if there is no request.
+// It returns ErrParseError if the frame is not a valid JSON.
+func splitMessages(data []byte) (requests []byte, responses []Response[json.RawMessage], err error) {
+	if !json.Valid(data) {
+		return nil, nil, ErrParseError
+	}
+
+	data = bytes.TrimSpace(data)
+	if data[0] != '[' {
+		if isResponse(data) {
+			return nil, decodeResponses([]json.RawMessage{data}), nil
+		}
+		return data, nil, nil
+	}
+
+	var elems []json.RawMessage
+	if err := json.Unmarshal(data, &elems); err != nil {
+		return nil, nil, ErrParseError
+	}
+	if len(elems) == 0 {
+		// Let decodeRequests report the empty batch.
+		return data, nil, nil
+	}
+
+	var reqs []json.RawMessage
+	for _, elem := range elems {
+		if !isResponse(elem) {
+			reqs = append(reqs, elem)
+		}
+	}
+	responses = decodeResponses(elems)
+
+	if len(reqs) == 0 {
+		return nil, responses, nil
+	}
+	requests, err = json.Marshal(reqs)
+	return requests, responses, err
+}
+
+// decodeResponses decodes responses in the messages, and ignores requests.
+// Malformed responses are also ignored, because the peer does not wait for a reply to them.
+func decodeResponses(elems []json.RawMessage) []Response[json.RawMessage] {
+	var responses []Response[json.RawMessage]
+	for _, elem := range elems {
+		if !isResponse(elem) {
+			continue
+		}
+		var res Response[json.RawMessage]
+		if err := json.Unmarshal(elem, &res); err == nil {
+			responses = append(responses, res)
+		}
+	}
+	return responses
+}
+
+func (c *Conn) run(ctx context.Context) {
+	parseError := func() {
+		c.w.WriteFrame(parseErrorResponse)
+	}
+
+	for {
+		data, ok := c.client.readFrame(ctx, parseError)
+		if !ok {
+			return
+		}
+
+		reqData, responses, err := splitMessages(data)
+		if err != nil {
+			parseError()
+			continue
+		}
+
+		for _, res := range responses {
+			c.client.onResponse(res)
+		}
+
+		if reqData == nil {
+			continue
+		}
+
+		// Requests are decoded in the same way as `Server`, so that invalid requests are replied with `ErrInvalidRequest`.
+		reqs, invalids, err := decodeRequests(reqData)
+		if err != nil {
+			parseError()
+			continue
+		}
+
+		if len(reqs.Messages) == 0 {
+			c.server.callAllAndWrite(ctx, c.w, reqs, invalids)
+			continue
+		}
+
+		// Handle cancellation immediately, because it should not wait for the semaphore or other requests.
+		if !reqs.IsBatch && reqs.Messages[0].ID == nil && c.server.handleCancel(ctx, reqs.Messages[0]) {
+			continue
+		}
+
+		// Reject the request if too many requests are waiting, so that a flooding peer cannot create goroutines without limit.
+		if !c.server.reserve() {
+			writeBusy(c.w, reqs, invalids)
+			continue
+		}
+
+		if !c.server.startRequest() {
+			// The connection is shutting down.
+			c.server.release()
+			continue
+		}
+
+		// Register the request before waiting for the semaphore, so that the peer can cancel it while queued.
+		rctx, done := ctx, func() {}
+		if !reqs.IsBatch {
+			rctx, done = trackRequest(ctx, reqs.Messages[0])
+		}
+
+		go func() {
+			defer c.server.release()
+			defer c.server.finishRequest()
+			defer done()
+
+			// Handlers may call the peer and wait for the reply, so the semaphore should not block the reading loop.
+			// Each request in a batch takes the semaphore in callAll.
+			if !reqs.IsBatch && c.server.acquire(rctx) {
+				defer func() { <-c.server.semaphore }()
+			}
+
+			c.server.callAllAndWrite(rctx, c.w, reqs, invalids)
+		}()
+	}
+}
+
+// Close stops the connection.
+//
+// This method cancels the contexts of running handlers, but does not wait for them.
+// Please use `Shutdown` to wait for them.
+//
+// This method does not close the underlying read-writer.
+// A connection cannot be used after it is closed.
+func (c *Conn) Close() error {
+	return c.client.Close()
+}
+
+// Shutdown stops the connection like `Close`, and waits for running handlers to return.
+//
+// If the `ctx` expires before all handlers return, Shutdown returns the context's error.
+func (c *Conn) Shutdown(ctx context.Context) error {
+	err := c.client.Close()
+	if serr := c.server.Shutdown(ctx); serr != nil {
+		return serr
+	}
+	return err
+}
+
+// Done returns a channel that is closed when the connection stops.
+// See `Client.Done` for details.
+func (c *Conn) Done() <-chan struct{} {
+	return c.client.Done()
+}
+
+// Err returns the reason why the connection stopped.
+// See `Client.Err` for details.
+func (c *Conn) Err() error {
+	return c.client.Err()
+}
+
+// Call calls a method on the peer.
+//
+// The response from the peer is unmarshaled into the `result` parameter.
+// If you do not need the response, use `Notify` instead.
+func (c *Conn) Call(ctx context.Context, name string, params any, result any) error {
+	return c.client.Call(ctx, name, params, result)
+}
+
+// Notify sends a notification to the peer.
+func (c *Conn) Notify(ctx context.Context, name string, params any) error {
+	return c.client.Notify(ctx, name, params)
+}
+
+// Batch sends multiple requests to the peer at once.
+func (c *Conn) Batch(ctx context.Context, reqs []BatchRequest) ([]*BatchResponse, error) {
+	return c.client.Batch(ctx, reqs)
+}
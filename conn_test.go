@@ -0,0 +1,317 @@
+package jsonrpc2_test
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/macrat/go-jsonrpc2"
+)
+
+func TestConn(t *testing.T) {
+	t.Parallel()
+
+	left, right := BiDirectionalPipe(t)
+	defer left.Close()
+	defer right.Close()
+
+	server := jsonrpc2.NewServer()
+	server.On("greet", jsonrpc2.Call(func(ctx context.Context, name string) (string, error) {
+		return "hello " + name, nil
+	}))
+
+	a := jsonrpc2.NewConn(left, nil)
+	defer a.Close()
+
+	b := jsonrpc2.NewConn(right, server)
+	defer b.Close()
+
+	a.On("add", jsonrpc2.Call(func(ctx context.Context, xs []int) (int, error) {
+		sum := 0
+		for _, x := range xs {
+			sum += x
+		}
+		return sum, nil
+	}))
+
+	// A method that calls back to the peer while handling a request.
+	a.On("greetBack", jsonrpc2.Call(func(ctx context.Context, name string) (string, error) {
+		var s string
+		err := a.Call(ctx, "greet", name, &s)
+		return s, err
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var sum int
+	if err := b.Call(ctx, "add", []int{1, 2, 3}, &sum); err != nil {
+		t.Fatalf("failed to call add: %s", err)
+	}
+	if sum != 6 {
+		t.Errorf("unexpected result of add: %d", sum)
+	}
+
+	var greet string
+	if err := a.Call(ctx, "greet", "alice", &greet); err != nil {
+		t.Fatalf("failed to call greet: %s", err)
+	}
+	if greet != "hello alice" {
+		t.Errorf("unexpected result of greet: %q", greet)
+	}
+
+	if err := b.Call(ctx, "greetBack", "bob", &greet); err != nil {
+		t.Fatalf("failed to call greetBack: %s", err)
+	}
+	if greet != "hello bob" {
+		t.Errorf("unexpected result of greetBack: %q", greet)
+	}
+
+	var notFound any
+	if err := a.Call(ctx, "add", []int{1, 2}, &notFound); err == nil {
+		t.Errorf("unexpected success of add on b: %v", notFound)
+	} else if err.Error() != "Method not found (-32601)" {
+		t.Errorf("unexpected error of add on b: %s", err)
+	}
+
+	res, err := b.Batch(ctx, []jsonrpc2.BatchRequest{
+		{Method: "add", Params: []int{1, 2}},
+		{Method: "greetBack", Params: "carol"},
+	})
+	if err != nil {
+		t.Fatalf("failed to call batch: %s", err)
+	}
+	expected := []*jsonrpc2.BatchResponse{
+		{Method: "add", Params: []int{1, 2}, Result: []byte("3")},
+		{Method: "greetBack", Params: "carol", Result: []byte(`"hello carol"`)},
+	}
+	if diff := cmp.Diff(expected, res); diff != "" {
+		t.Errorf("unexpected result of batch:\n%s", diff)
+	}
+}
+
+func TestConn_invalid(t *testing.T) {
+	t.Parallel()
+
+	left, right := BiDirectionalPipe(t)
+	defer left.Close()
+	defer right.Close()
+
+	conn := jsonrpc2.NewConn(left, nil, jsonrpc2.WithClientFraming(jsonrpc2.NewlineFraming{}))
+	defer conn.Close()
+	conn.On("echo", jsonrpc2.Call(func(ctx context.Context, s string) (string, error) {
+		return s, nil
+	}))
+
+	tests := []struct {
+		Name   string
+		Input  string
+		Output string
+	}{
+		{
+			"parse-error",
+			`{"jsonrpc":"2.0",`,
+			`{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`,
+		},
+		{
+			"invalid-version",
+			`{"jsonrpc":"1.0","method":"echo","params":"a","id":1}`,
+			`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"Invalid version: \"jsonrpc\" must be exactly \"2.0\" but got \"1.0\""},"id":1}`,
+		},
+		{
+			"no-method",
+			`{"jsonrpc":"2.0","params":"a","id":2}`,
+			`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"\"method\" must be a non-empty string"},"id":2}`,
+		},
+		{
+			"partially-invalid-batch",
+			`[1,{"jsonrpc":"2.0","method":"echo","params":"b","id":3}]`,
+			`[{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"request must be an object"},"id":null},{"jsonrpc":"2.0","result":"b","id":3}]`,
+		},
+		{
+			"empty-batch",
+			`[]`,
+			`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"batch must not be empty"},"id":null}`,
+		},
+		{
+			// Responses are not replied, so the reply is for the next request.
+			"response",
+			`{"jsonrpc":"2.0","result":1,"id":98}` + "\n" + `{"jsonrpc":"2.0","method":"echo","params":"c","id":4}`,
+			`{"jsonrpc":"2.0","result":"c","id":4}`,
+		},
+		{
+			"batch-with-response",
+			`[{"jsonrpc":"2.0","error":{"code":1,"message":"x"},"id":99},{"jsonrpc":"2.0","method":"echo","params":"d","id":5}]`,
+			`[{"jsonrpc":"2.0","result":"d","id":5}]`,
+		},
+	}
+
+	r := jsonrpc2.NewlineFraming{}.NewReader(right)
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			go right.Write([]byte(tt.Input + "\n"))
+
+			frame, err := r.ReadFrame()
+			if err != nil {
+				t.Fatalf("failed to read: %s", err)
+			}
+			if string(frame) != tt.Output {
+				t.Errorf("unexpected response:\nexpected: %s\n but got: %s", tt.Output, frame)
+			}
+		})
+	}
+}
+
+func TestConn_serverOptions(t *testing.T) {
+	t.Parallel()
+
+	left, right := BiDirectionalPipe(t)
+	defer left.Close()
+	defer right.Close()
+
+	var called atomic.Int64
+	counter := func(next jsonrpc2.Handler) jsonrpc2.Handler {
+		return jsonrpc2.HandlerFunc(func(ctx context.Context, r jsonrpc2.RawRequest) (any, error) {
+			called.Add(1)
+			return next.ServeJSONRPC2(ctx, r)
+		})
+	}
+
+	a := jsonrpc2.NewConn(left, nil, jsonrpc2.WithServerOptions(jsonrpc2.WithMiddleware(counter), jsonrpc2.WithStrictParams()))
+	defer a.Close()
+	a.On("add", jsonrpc2.Call(func(ctx context.Context, p struct{ X, Y int }) (int, error) {
+		return p.X + p.Y, nil
+	}))
+
+	b := jsonrpc2.NewConn(right, nil)
+	defer b.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var n int
+	if err := b.Call(ctx, "add", map[string]int{"X": 1, "Y": 2}, &n); err != nil {
+		t.Fatalf("failed to call: %s", err)
+	} else if n != 3 {
+		t.Errorf("unexpected result: %d", n)
+	}
+
+	// Strict mode rejects unknown fields.
+	if err := b.Call(ctx, "add", map[string]int{"X": 1, "Z": 2}, &n); err == nil {
+		t.Errorf("expected an error for unknown field")
+	}
+
+	if c := called.Load(); c != 2 {
+		t.Errorf("unexpected number of calls of the middleware: %d", c)
+	}
+}
+
+// This test does not run in parallel, because it counts goroutines.
+func TestConn_flood(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	left, right := BiDirectionalPipe(t)
+	defer left.Close()
+	defer right.Close()
+
+	release := make(chan struct{})
+
+	conn := jsonrpc2.NewConn(left, nil, jsonrpc2.WithServerOptions(jsonrpc2.WithMaxConcurrentCalls(2), jsonrpc2.WithMaxQueuedCalls(3)))
+	defer conn.Close()
+	conn.On("wait", jsonrpc2.Call(func(ctx context.Context, n int) (int, error) {
+		<-release
+		return n, nil
+	}))
+
+	floodRequests(t, right, release, before)
+}
+
+func TestConn_On(t *testing.T) {
+	t.Parallel()
+
+	left, right := BiDirectionalPipe(t)
+	defer left.Close()
+	defer right.Close()
+
+	a := jsonrpc2.NewConn(left, nil)
+	defer a.Close()
+
+	b := jsonrpc2.NewConn(right, nil)
+	defer b.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Register methods while the peer is calling them.
+	var wg sync.WaitGroup
+	for i := range 10 {
+		name := fmt.Sprintf("m%d", i)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.On(name, jsonrpc2.Call(func(ctx context.Context, _ any) (string, error) {
+				return name, nil
+			}))
+		}()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var s string
+			b.Call(ctx, name, nil, &s)
+		}()
+	}
+	wg.Wait()
+
+	var s string
+	if err := b.Call(ctx, "m3", nil, &s); err != nil {
+		t.Fatalf("failed to call: %s", err)
+	} else if s != "m3" {
+		t.Errorf("unexpected result: %q", s)
+	}
+}
+
+func TestConn_Shutdown(t *testing.T) {
+	t.Parallel()
+
+	left, right := BiDirectionalPipe(t)
+	defer left.Close()
+	defer right.Close()
+
+	entered := make(chan struct{})
+	var finished atomic.Bool
+
+	a := jsonrpc2.NewConn(left, nil)
+	a.On("wait", jsonrpc2.Notify(func(ctx context.Context, _ any) error {
+		close(entered)
+		<-ctx.Done()
+		time.Sleep(100 * time.Millisecond)
+		finished.Store(true)
+		return nil
+	}))
+
+	b := jsonrpc2.NewConn(right, nil)
+	defer b.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := b.Notify(ctx, "wait", nil); err != nil {
+		t.Fatalf("failed to notify: %s", err)
+	}
+	<-entered
+
+	if err := a.Shutdown(ctx); err != nil {
+		t.Fatalf("failed to shutdown: %s", err)
+	}
+	if !finished.Load() {
+		t.Errorf("Shutdown returned before the handler returned")
+	}
+}
@@ -0,0 +1,394 @@
+package jsonrpc2
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"sync"
+
+	"github.com/goccy/go-json"
+)
+
+var (
+	// ErrInvalidFrame is returned by FrameReader if the stream has malformed data.
+	// The reader can continue to read the next frame after this error.
+	ErrInvalidFrame = errors.New("jsonrpc2: invalid frame")
+
+	// ErrInvalidHeader is returned by FrameReader of HeaderFraming if the header of a frame is malformed or too large.
+	// It wraps ErrInvalidFrame.
+	ErrInvalidHeader = fmt.Errorf("%w header", ErrInvalidFrame)
+)
+
+// Framing is an interface to split a byte stream into JSON-RPC 2.0 messages.
+//
+// `Server` and `Client` use `StreamFraming` by default.
+// Please use `WithFraming` or `WithClientFraming` to change it.
+//
+// Framing is not used for connections that are already message framed, such as WebSocket connections.
+// If the io.ReadWriter implements FrameReader and FrameWriter, the server and the client use it as-is.
+type Framing interface {
+	// NewReader creates a FrameReader that reads messages from `r`.
+	NewReader(r io.Reader) FrameReader
+
+	// NewWriter creates a FrameWriter that writes messages to `w`.
+	NewWriter(w io.Writer) FrameWriter
+}
+
+// FrameReader reads framed messages.
+type FrameReader interface {
+	// ReadFrame reads a single message.
+	// It returns io.EOF if there is no more message.
+	//
+	// If the stream has malformed data, it returns an error that wraps ErrInvalidFrame,
+	// and the next call reads the next frame.
+	// The returned frame is not guaranteed to be a valid JSON.
+	ReadFrame() ([]byte, error)
+}
+
+// FrameWriter writes framed messages.
+type FrameWriter interface {
+	// WriteFrame writes a single message.
+	WriteFrame(data []byte) error
+}
+
+// newFrameReader creates a FrameReader for `r`.
+// If `r` is a FrameReader by itself, it is used as-is instead of the framing.
+func newFrameReader(f Framing, r io.Reader) FrameReader {
+	if fr, ok := r.(FrameReader); ok {
+		return fr
+	}
+	return f.NewReader(r)
+}
+
+// newFrameWriter creates a FrameWriter for `w`.
+// If `w` is a FrameWriter by itself, it is used as-is instead of the framing.
+func newFrameWriter(f Framing, w io.Writer) FrameWriter {
+	if fw, ok := w.(FrameWriter); ok {
+		return fw
+	}
+	return f.NewWriter(w)
+}
+
+// writeMessage marshals `v` and writes it as a single frame.
+func writeMessage(w FrameWriter, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return w.WriteFrame(data)
+}
+
+// lockedFrameWriter is a FrameWriter that serializes writes.
+//
+// Each frame is written by a single Write call to the underlying writer,
+// so holding the lock while writing guarantees that messages are not interleaved.
+type lockedFrameWriter struct {
+	mu sync.Mutex
+	w  FrameWriter
+}
+
+func (l *lockedFrameWriter) WriteFrame(data []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.w.WriteFrame(data)
+}
+
+// defaultMaxFrameSize is the default value of MaxFrameSize of the framings.
+const defaultMaxFrameSize = 10 << 20
+
+// frameLimit returns the maximum frame size for the MaxFrameSize field of the framings.
+func frameLimit(maxFrameSize int64) int64 {
+	if maxFrameSize <= 0 {
+		return defaultMaxFrameSize
+	}
+	return maxFrameSize
+}
+
+// StreamFraming is a Framing that does not use any delimiter.
+// Messages are just concatenated JSON values.
+//
+// This framing writes a newline after each message for readability,
+// but it does not require newlines to read messages.
+//
+// The reader splits the stream by balancing brackets, so it cannot recover from unbalanced brackets.
+// Please use NewlineFraming or HeaderFraming if you need robust recovery from malformed data.
+type StreamFraming struct {
+	// MaxFrameSize is the maximum size of a received message in bytes.
+	// If it is zero, the default value is 10 MiB.
+	//
+	// Larger messages are skipped without reading into memory, and reported as ErrInvalidFrame.
+	MaxFrameSize int64
+}
+
+// NewReader implements the Framing interface.
+func (f StreamFraming) NewReader(r io.Reader) FrameReader {
+	return &streamReader{r: bufio.NewReader(r), limit: frameLimit(f.MaxFrameSize)}
+}
+
+// NewWriter implements the Framing interface.
+func (StreamFraming) NewWriter(w io.Writer) FrameWriter {
+	return &newlineWriter{w: w}
+}
+
+type streamReader struct {
+	r     *bufio.Reader
+	limit int64
+}
+
+func isJSONSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+func (r *streamReader) ReadFrame() ([]byte, error) {
+	var first byte
+	for {
+		b, err := r.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if !isJSONSpace(b) {
+			first = b
+			break
+		}
+	}
+
+	buf := []byte{first}
+	tooLarge := false
+
+	// push appends a byte to the frame unless the frame is too large.
+	// The rest of a large frame is still scanned to find the end of it, but not kept in memory.
+	push := func(b byte) {
+		if tooLarge {
+			return
+		}
+		if int64(len(buf)) >= r.limit {
+			tooLarge = true
+			buf = nil
+			return
+		}
+		buf = append(buf, b)
+	}
+
+	switch first {
+	case '{', '[', '"':
+		depth := 0
+		inString := first == '"'
+		escaped := false
+		if !inString {
+			depth = 1
+		}
+
+		for depth > 0 || inString {
+			b, err := r.r.ReadByte()
+			if errors.Is(err, io.EOF) {
+				return nil, io.ErrUnexpectedEOF
+			} else if err != nil {
+				return nil, err
+			}
+			push(b)
+
+			switch {
+			case escaped:
+				escaped = false
+			case inString && b == '\\':
+				escaped = true
+			case b == '"':
+				inString = !inString
+			case inString:
+			case b == '{' || b == '[':
+				depth++
+			case b == '}' || b == ']':
+				depth--
+			}
+		}
+	case '}', ']':
+		// Unbalanced closing bracket. Return it as a frame to report a parse error.
+	default:
+		// Scalar values such as numbers, or garbage.
+		for {
+			b, err := r.r.ReadByte()
+			if errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+			if isJSONSpace(b) || bytes.IndexByte([]byte(`{}[]"`), b) >= 0 {
+				r.r.UnreadByte()
+				break
+			}
+			push(b)
+		}
+	}
+
+	if tooLarge {
+		return nil, fmt.Errorf("%w: frame exceeds the limit %d", ErrInvalidFrame, r.limit)
+	}
+	return buf, nil
+}
+
+// NewlineFraming is a Framing that separates messages by newline, also known as JSON Lines.
+type NewlineFraming struct {
+	// MaxFrameSize is the maximum size of a received line in bytes, excluding the newline.
+	// If it is zero, the default value is 10 MiB.
+	//
+	// Longer lines are skipped without reading into memory, and reported as ErrInvalidFrame.
+	MaxFrameSize int64
+}
+
+// NewReader implements the Framing interface.
+func (f NewlineFraming) NewReader(r io.Reader) FrameReader {
+	return &newlineReader{r: bufio.NewReader(r), limit: frameLimit(f.MaxFrameSize)}
+}
+
+// NewWriter implements the Framing interface.
+func (NewlineFraming) NewWriter(w io.Writer) FrameWriter {
+	return &newlineWriter{w: w}
+}
+
+type newlineReader struct {
+	r     *bufio.Reader
+	limit int64
+}
+
+func (r *newlineReader) ReadFrame() ([]byte, error) {
+	for {
+		line, err := r.readLine()
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			return line, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// readLine reads a line including the newline.
+// If the line is longer than the limit, it skips the line and returns an error that wraps ErrInvalidFrame.
+func (r *newlineReader) readLine() ([]byte, error) {
+	var line []byte
+	tooLarge := false
+
+	for {
+		chunk, err := r.r.ReadSlice('\n')
+
+		if !tooLarge {
+			size := int64(len(line) + len(chunk))
+			if bytes.HasSuffix(chunk, []byte{'\n'}) {
+				size--
+			}
+			if size > r.limit {
+				tooLarge = true
+				line = nil
+			} else {
+				line = append(line, chunk...)
+			}
+		}
+
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if tooLarge {
+			if err != nil && !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: line exceeds the limit %d", ErrInvalidFrame, r.limit)
+		}
+		return line, err
+	}
+}
+
+type newlineWriter struct {
+	w io.Writer
+}
+
+func (w *newlineWriter) WriteFrame(data []byte) error {
+	buf := make([]byte, 0, len(data)+1)
+	buf = append(buf, data...)
+	buf = append(buf, '\n')
+	_, err := w.w.Write(buf)
+	return err
+}
+
+// HeaderFraming is a Framing that uses `Content-Length` header for each message, like Language Server Protocol.
+//
+//	Content-Length: 52\r\n
+//	\r\n
+//	{"jsonrpc":"2.0","method":"initialized","params":{}}
+//
+// Other headers such as `Content-Type` are ignored when reading, and not written.
+type HeaderFraming struct {
+	// MaxFrameSize is the maximum Content-Length of a received message in bytes.
+	// If it is zero, the default value is 10 MiB.
+	//
+	// Larger messages are skipped without reading into memory, and reported as ErrInvalidHeader.
+	MaxFrameSize int64
+}
+
+// NewReader implements the Framing interface.
+func (f HeaderFraming) NewReader(r io.Reader) FrameReader {
+	return &headerReader{r: textproto.NewReader(bufio.NewReader(r)), limit: frameLimit(f.MaxFrameSize)}
+}
+
+// NewWriter implements the Framing interface.
+func (HeaderFraming) NewWriter(w io.Writer) FrameWriter {
+	return &headerWriter{w: w}
+}
+
+type headerReader struct {
+	r     *textproto.Reader
+	limit int64
+}
+
+func (r *headerReader) ReadFrame() ([]byte, error) {
+	header, err := r.r.ReadMIMEHeader()
+	if err != nil {
+		var perr textproto.ProtocolError
+		switch {
+		case errors.As(err, &perr):
+			return nil, fmt.Errorf("%w: %w", ErrInvalidHeader, err)
+		case errors.Is(err, io.EOF) && len(header) > 0:
+			return nil, io.ErrUnexpectedEOF
+		default:
+			// I/O errors are not recoverable, so they should not be reported as ErrInvalidFrame.
+			return nil, err
+		}
+	}
+
+	length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("%w: invalid Content-Length: %q", ErrInvalidHeader, header.Get("Content-Length"))
+	}
+	if length > r.limit {
+		// Skip the body to read the next frame.
+		// If the stream ends while skipping, the next call reports it.
+		io.CopyN(io.Discard, r.r.R, length)
+		return nil, fmt.Errorf("%w: Content-Length %d exceeds the limit %d", ErrInvalidHeader, length, r.limit)
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r.r.R, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+type headerWriter struct {
+	w io.Writer
+}
+
+func (w *headerWriter) WriteFrame(data []byte) error {
+	buf := make([]byte, 0, len(data)+32)
+	buf = append(buf, "Content-Length: "...)
+	buf = strconv.AppendInt(buf, int64(len(data)), 10)
+	buf = append(buf, "\r\n\r\n"...)
+	buf = append(buf, data...)
+	_, err := w.w.Write(buf)
+	return err
+}
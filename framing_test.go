@@ -0,0 +1,293 @@
+package jsonrpc2_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/macrat/go-jsonrpc2"
+)
+
+func TestFraming(t *testing.T) {
+	t.Parallel()
+
+	messages := []string{
+		`{"jsonrpc":"2.0","method":"hello","params":"world","id":1}`,
+		`[{"jsonrpc":"2.0","method":"notify"}]`,
+		`{"jsonrpc":"2.0","result":"multi\nline","id":"x"}`,
+	}
+
+	tests := []struct {
+		Name    string
+		Framing jsonrpc2.Framing
+		Output  string
+	}{
+		{
+			Name:    "stream",
+			Framing: jsonrpc2.StreamFraming{},
+			Output:  messages[0] + "\n" + messages[1] + "\n" + messages[2] + "\n",
+		},
+		{
+			Name:    "newline",
+			Framing: jsonrpc2.NewlineFraming{},
+			Output:  messages[0] + "\n" + messages[1] + "\n" + messages[2] + "\n",
+		},
+		{
+			Name:    "header",
+			Framing: jsonrpc2.HeaderFraming{},
+			Output: "Content-Length: 58\r\n\r\n" + messages[0] +
+				"Content-Length: 37\r\n\r\n" + messages[1] +
+				"Content-Length: 49\r\n\r\n" + messages[2],
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			w := tt.Framing.NewWriter(&buf)
+			for _, m := range messages {
+				if err := w.WriteFrame([]byte(m)); err != nil {
+					t.Fatalf("failed to write frame: %s", err)
+				}
+			}
+
+			if buf.String() != tt.Output {
+				t.Errorf("unexpected output:\nwant: %q\n got: %q", tt.Output, buf.String())
+			}
+
+			r := tt.Framing.NewReader(&buf)
+			for i, m := range messages {
+				data, err := r.ReadFrame()
+				if err != nil {
+					t.Fatalf("failed to read frame %d: %s", i, err)
+				}
+				if string(data) != m {
+					t.Errorf("unexpected frame %d:\nwant: %s\n got: %s", i, m, data)
+				}
+			}
+
+			if _, err := r.ReadFrame(); !errors.Is(err, io.EOF) {
+				t.Errorf("expected EOF but got %v", err)
+			}
+		})
+	}
+}
+
+func TestHeaderFraming_read(t *testing.T) {
+	t.Parallel()
+
+	input := "Content-Type: application/vscode-jsonrpc; charset=utf-8\r\ncontent-length: 2\r\n\r\n{}" +
+		"Content-Length: 4\r\n\r\nnull" +
+		"Content-Length: abc\r\n\r\n{}"
+
+	r := jsonrpc2.HeaderFraming{}.NewReader(strings.NewReader(input))
+
+	for _, want := range []string{"{}", "null"} {
+		data, err := r.ReadFrame()
+		if err != nil {
+			t.Fatalf("failed to read frame: %s", err)
+		}
+		if string(data) != want {
+			t.Errorf("unexpected frame: want=%q got=%q", want, data)
+		}
+	}
+
+	if _, err := r.ReadFrame(); !errors.Is(err, jsonrpc2.ErrInvalidHeader) {
+		t.Errorf("expected ErrInvalidHeader but got %v", err)
+	}
+}
+
+func TestHeaderFraming_readLarge(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name    string
+		Framing jsonrpc2.HeaderFraming
+		Input   string
+		Next    string
+	}{
+		{"huge", jsonrpc2.HeaderFraming{}, "Content-Length: 9223372036854775807\r\n\r\n{}", ""},
+		{"over-default", jsonrpc2.HeaderFraming{}, "Content-Length: 10485761\r\n\r\n{}", ""},
+		{"over-limit", jsonrpc2.HeaderFraming{MaxFrameSize: 4}, "Content-Length: 5\r\n\r\n[1,2]Content-Length: 4\r\n\r\nnull", "null"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := tt.Framing.NewReader(strings.NewReader(tt.Input))
+
+			if _, err := r.ReadFrame(); !errors.Is(err, jsonrpc2.ErrInvalidHeader) {
+				t.Fatalf("expected ErrInvalidHeader but got %v", err)
+			}
+
+			// The reader can continue after the skipped frame.
+			data, err := r.ReadFrame()
+			if tt.Next == "" {
+				if !errors.Is(err, io.EOF) {
+					t.Errorf("expected EOF but got %q, %v", data, err)
+				}
+			} else if err != nil || string(data) != tt.Next {
+				t.Errorf("unexpected next frame: %q, %v", data, err)
+			}
+		})
+	}
+}
+
+func TestFraming_readLarge(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("x", 10000)
+
+	tests := []struct {
+		Name    string
+		Framing jsonrpc2.Framing
+		Input   string
+		Output  []string // Output is the expected frames, or "" for ErrInvalidFrame.
+	}{
+		{
+			"stream",
+			jsonrpc2.StreamFraming{MaxFrameSize: 4},
+			`[1,2,3] null "abcdef" 12345 true {"a":[1]}[1]`,
+			[]string{"", "null", "", "", "true", "", "[1]"},
+		},
+		{
+			"stream-long",
+			jsonrpc2.StreamFraming{MaxFrameSize: 5000},
+			`["` + long + `"]{}`,
+			[]string{"", "{}"},
+		},
+		{
+			"newline",
+			jsonrpc2.NewlineFraming{MaxFrameSize: 4},
+			"12345\nnull\n  ab  \n{}\n1234",
+			[]string{"", "null", "", "{}", "1234"},
+		},
+		{
+			"newline-long",
+			jsonrpc2.NewlineFraming{MaxFrameSize: 5000},
+			long + "\nnull\n" + long,
+			[]string{"", "null", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := tt.Framing.NewReader(strings.NewReader(tt.Input))
+
+			for i, want := range tt.Output {
+				data, err := r.ReadFrame()
+				if want == "" {
+					if !errors.Is(err, jsonrpc2.ErrInvalidFrame) {
+						t.Fatalf("%d: expected ErrInvalidFrame but got %q, %v", i, data, err)
+					}
+				} else if err != nil || string(data) != want {
+					t.Fatalf("%d: expected %q but got %q, %v", i, want, data, err)
+				}
+			}
+
+			if data, err := r.ReadFrame(); !errors.Is(err, io.EOF) {
+				t.Errorf("expected EOF but got %q, %v", data, err)
+			}
+		})
+	}
+}
+
+func TestHeaderFraming_readClosed(t *testing.T) {
+	t.Parallel()
+
+	cli, srv := net.Pipe()
+	cli.Close()
+
+	r := jsonrpc2.HeaderFraming{}.NewReader(srv)
+	if _, err := r.ReadFrame(); err == nil || errors.Is(err, jsonrpc2.ErrInvalidFrame) {
+		t.Errorf("I/O error should not be ErrInvalidFrame but got %v", err)
+	}
+
+	r = jsonrpc2.HeaderFraming{}.NewReader(strings.NewReader("Content-Length: 2\r\n"))
+	if _, err := r.ReadFrame(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected io.ErrUnexpectedEOF but got %v", err)
+	}
+}
+
+func Test_clientAndServer_headerFraming(t *testing.T) {
+	t.Parallel()
+
+	cli, srv := BiDirectionalPipe(t)
+	defer cli.Close()
+
+	server := jsonrpc2.NewServer(jsonrpc2.WithFraming(jsonrpc2.HeaderFraming{}))
+	server.On("add", jsonrpc2.Call(func(ctx context.Context, params []int) (int, error) {
+		sum := 0
+		for _, n := range params {
+			sum += n
+		}
+		return sum, nil
+	}))
+	go server.ServeForOne(srv)
+
+	client := jsonrpc2.NewClient(cli, jsonrpc2.WithClientFraming(jsonrpc2.HeaderFraming{}))
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var sum int
+	if err := client.Call(ctx, "add", []int{1, 2, 3}, &sum); err != nil {
+		t.Fatalf("failed to call add: %s", err)
+	}
+	if sum != 6 {
+		t.Errorf("unexpected result of add: %d", sum)
+	}
+
+	res, err := client.Batch(ctx, []jsonrpc2.BatchRequest{
+		{Method: "add", Params: []int{1, 2}},
+		{Method: "add", Params: []int{3, 4}},
+	})
+	if err != nil {
+		t.Fatalf("failed to call batch: %s", err)
+	}
+	if len(res) != 2 || string(res[0].Result) != "3" || string(res[1].Result) != "7" {
+		t.Errorf("unexpected result of batch: %v", res)
+	}
+}
+
+func TestStreamFraming_read(t *testing.T) {
+	t.Parallel()
+
+	input := `{"a":"}{\"]"} [1,[2]]abc "str\"ing" 123}{"b":2}` + "\n" + `null`
+
+	r := jsonrpc2.StreamFraming{}.NewReader(strings.NewReader(input))
+
+	expected := []string{
+		`{"a":"}{\"]"}`,
+		`[1,[2]]`,
+		`abc`,
+		`"str\"ing"`,
+		`123`,
+		`}`,
+		`{"b":2}`,
+		`null`,
+	}
+	for _, want := range expected {
+		data, err := r.ReadFrame()
+		if err != nil {
+			t.Fatalf("failed to read frame: %s", err)
+		}
+		if string(data) != want {
+			t.Errorf("unexpected frame: want=%q got=%q", want, data)
+		}
+	}
+
+	if _, err := r.ReadFrame(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected EOF but got %v", err)
+	}
+
+	r = jsonrpc2.StreamFraming{}.NewReader(strings.NewReader(`{"a":[1`))
+	if _, err := r.ReadFrame(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected ErrUnexpectedEOF but got %v", err)
+	}
+}
@@ -3,6 +3,6 @@ module github.com/macrat/go-jsonrpc2
 go 1.23.4
 
 require (
-	github.com/goccy/go-json v0.10.4
+	github.com/goccy/go-json v0.10.5
 	github.com/google/go-cmp v0.6.0
 )
@@ -0,0 +1,247 @@
+package jsonrpc2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/goccy/go-json"
+)
+
+// WithMaxRequestSize specifies the maximum size of a request body in bytes for `Server.ServeHTTP`.
+// If this option is not specified, the default value is 10 MiB.
+//
+// maxSize must be greater than 0.
+func WithMaxRequestSize(maxSize int64) ServerOption {
+	if maxSize <= 0 {
+		panic("maxSize must be greater than 0")
+	}
+	return func(s *Server) {
+		s.maxRequestSize = maxSize
+	}
+}
+
+// ServeHTTP implements the http.Handler interface.
+//
+// The server accepts only POST requests with JSON body.
+// It responds 204 No Content if the request contains only notifications,
+// and 400 Bad Request if the request body is not a valid JSON or a valid JSON-RPC 2.0 request.
+// Invalid requests in a batch are responded with errors in 200 OK, like other requests in the batch.
+// Request bodies larger than the limit of `WithMaxRequestSize` are responded with 413 Request Entity Too Large.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		if mt, _, err := mime.ParseMediaType(ct); err != nil || mt != "application/json" {
+			http.Error(w, "Unsupported Media Type", http.StatusUnsupportedMediaType)
+			return
+		}
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.maxRequestSize))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		detail := fmt.Sprintf("request body must not be larger than %d bytes", tooLarge.Limit)
+		writeHTTPResponse(w, http.StatusRequestEntityTooLarge, invalidRequest(NullID(), detail))
+		return
+	} else if err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	rs, invalids, err := decodeRequests(body)
+	if err != nil {
+		writeHTTPResponse(w, http.StatusBadRequest, NewErrorResponse(NullID(), ErrParseError))
+		return
+	}
+	if !rs.IsBatch && len(invalids) > 0 {
+		writeHTTPResponse(w, http.StatusBadRequest, invalids[0])
+		return
+	}
+
+	res := s.callAll(r.Context(), rs)
+	res.Messages = append(invalids, res.Messages...)
+	if len(res.Messages) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	writeHTTPResponse(w, http.StatusOK, res)
+}
+
+func writeHTTPResponse(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// HTTPStatusError is an error for unexpected HTTP status from the server.
+type HTTPStatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e HTTPStatusError) Error() string {
+	return fmt.Sprintf("jsonrpc2: unexpected HTTP status: %s", e.Status)
+}
+
+// HTTPClient is a JSON-RPC 2.0 client that sends requests over HTTP.
+//
+// HTTPClient has the same API as `Client`, but each call is sent as a separate HTTP POST request.
+type HTTPClient struct {
+	caller
+
+	url    string
+	client *http.Client
+}
+
+// NewHTTPClient creates a new JSON-RPC 2.0 client over HTTP.
+//
+// The `url` parameter is the endpoint of the server.
+// The `client` parameter is used to send HTTP requests. If it is nil, http.DefaultClient is used.
+func NewHTTPClient(url string, client *http.Client, opts ...ClientOption) *HTTPClient {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	c := &HTTPClient{
+		url:    url,
+		client: client,
+	}
+	c.caller.init(newClientConfig(opts), c.roundTrip, c.roundTripBatch)
+
+	return c
+}
+
+// post sends `body` to the server and unmarshals the response into `result`.
+// It returns false without error if the server responded no content.
+func (c *HTTPClient) post(ctx context.Context, body any, result any) (bool, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return false, nil
+	}
+
+	mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mt != "application/json" {
+		if resp.StatusCode < 200 || 300 <= resp.StatusCode {
+			return false, HTTPStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+		}
+		return false, fmt.Errorf("jsonrpc2: unexpected Content-Type: %q", resp.Header.Get("Content-Type"))
+	}
+
+	return true, json.NewDecoder(resp.Body).Decode(result)
+}
+
+// roundTrip is the Invoker that sends a request as an HTTP request.
+func (c *HTTPClient) roundTrip(ctx context.Context, req *Request[any]) (*Response[json.RawMessage], error) {
+	var res Response[json.RawMessage]
+	if ok, err := c.post(ctx, wireRequest(req), &res); err != nil {
+		return nil, err
+	} else if !ok {
+		if req.ID == nil {
+			return nil, nil
+		}
+		return nil, HTTPStatusError{StatusCode: http.StatusNoContent, Status: "204 No Content"}
+	}
+
+	// A response with null ID is an error that the server could not read the request.
+	// Other responses must be for the request, the same as responses in a batch.
+	if req.ID != nil {
+		got := "null"
+		if res.ID != nil {
+			got = res.ID.String()
+		}
+		if got != req.ID.String() && !(got == "null" && res.Error != nil) {
+			return nil, fmt.Errorf("%w: %s: the server responded for ID %s", ErrNoResponse, req.ID, got)
+		}
+	}
+
+	return &res, nil
+}
+
+// roundTripBatch is the BatchInvoker that sends requests as an HTTP request.
+func (c *HTTPClient) roundTripBatch(ctx context.Context, reqs []*Request[any]) ([]*Response[json.RawMessage], error) {
+	req := messageList[Request[any]]{
+		IsBatch:  true,
+		Messages: make([]Request[any], len(reqs)),
+	}
+	for i, r := range reqs {
+		req.Messages[i] = wireRequest(r)
+	}
+
+	var res messageList[Response[json.RawMessage]]
+	if _, err := c.post(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]Response[json.RawMessage], len(res.Messages))
+	for _, r := range res.Messages {
+		if r.ID != nil && r.ID.Raw() != nil {
+			byID[r.ID.String()] = r
+		} else if r.Error != nil {
+			// The server could not read the batch at all.
+			return nil, r.Error
+		}
+	}
+
+	resps := make([]*Response[json.RawMessage], len(reqs))
+	for i, r := range reqs {
+		if r.ID == nil {
+			continue
+		}
+
+		res, ok := byID[r.ID.String()]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrNoResponse, r.ID)
+		}
+		resps[i] = &res
+	}
+
+	return resps, nil
+}
+
+// Call calls a method on the server.
+//
+// The response from the server is unmarshaled into the `result` parameter.
+// If you do not need the response, use `Notify` instead.
+func (c *HTTPClient) Call(ctx context.Context, name string, params any, result any) error {
+	return c.caller.call(ctx, name, params, result)
+}
+
+// Notify sends a notification to the server.
+//
+// Even if the server replies something, the client will not receive it.
+// If you need the response, use `Call` instead.
+func (c *HTTPClient) Notify(ctx context.Context, name string, params any) error {
+	return c.caller.notify(ctx, name, params)
+}
+
+// Batch sends multiple requests to the server at once.
+func (c *HTTPClient) Batch(ctx context.Context, reqs []BatchRequest) ([]*BatchResponse, error) {
+	return c.caller.batch(ctx, reqs)
+}
@@ -0,0 +1,319 @@
+package jsonrpc2_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/macrat/go-jsonrpc2"
+)
+
+func StartHTTPServer(t *testing.T) (url string, notificationCount *uint64) {
+	notificationCount = new(uint64)
+
+	server := jsonrpc2.NewServer()
+
+	server.On("add", jsonrpc2.Call(func(ctx context.Context, params []int) (int, error) {
+		sum := 0
+		for _, n := range params {
+			sum += n
+		}
+		return sum, nil
+	}))
+
+	server.On("count", jsonrpc2.Notify(func(ctx context.Context, params int) error {
+		atomic.AddUint64(notificationCount, uint64(params))
+		return nil
+	}))
+
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	return ts.URL, notificationCount
+}
+
+func TestHTTPClient(t *testing.T) {
+	t.Parallel()
+
+	url, count := StartHTTPServer(t)
+
+	client := jsonrpc2.NewHTTPClient(url, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var sum int
+	if err := client.Call(ctx, "add", []int{1, 2, 3}, &sum); err != nil {
+		t.Fatalf("failed to call add: %s", err)
+	}
+	if sum != 6 {
+		t.Errorf("unexpected result of add: %d", sum)
+	}
+
+	if err := client.Notify(ctx, "count", 10); err != nil {
+		t.Fatalf("failed to notify count: %s", err)
+	}
+	if n := atomic.LoadUint64(count); n != 10 {
+		t.Errorf("unexpected notification count: %d", n)
+	}
+
+	var notFound any
+	if err := client.Call(ctx, "notFound", nil, &notFound); err == nil {
+		t.Errorf("unexpected success of notFound: %v", notFound)
+	} else if err.Error() != "Method not found (-32601)" {
+		t.Errorf("unexpected error of notFound: %s", err)
+	}
+
+	res, err := client.Batch(ctx, []jsonrpc2.BatchRequest{
+		{Method: "add", Params: []int{1, 2}},
+		{Method: "count", Params: 5, IsNotify: true},
+		{Method: "notFound"},
+	})
+	if err != nil {
+		t.Fatalf("failed to call batch: %s", err)
+	}
+	expected := []*jsonrpc2.BatchResponse{
+		{Method: "add", Params: []int{1, 2}, Result: []byte("3")},
+		nil,
+		{Method: "notFound", Error: &jsonrpc2.ErrMethodNotFound},
+	}
+	if diff := cmp.Diff(expected, res); diff != "" {
+		t.Errorf("unexpected result of batch:\n%s", diff)
+	}
+	if n := atomic.LoadUint64(count); n != 15 {
+		t.Errorf("unexpected notification count: %d", n)
+	}
+}
+
+func TestHTTPClient_mismatchedID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name     string
+		Response string
+		Error    string
+	}{
+		{"match", `{"jsonrpc":"2.0","result":1,"id":1}`, ""},
+		{"mismatch", `{"jsonrpc":"2.0","result":2,"id":2}`, "jsonrpc2: no response for the request: 1: the server responded for ID 2"},
+		{"no-id", `{"jsonrpc":"2.0","result":3}`, "jsonrpc2: no response for the request: 1: the server responded for ID null"},
+		{"null-id-error", `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`, "Parse error (-32700)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(tt.Response))
+			}))
+			defer ts.Close()
+
+			client := jsonrpc2.NewHTTPClient(ts.URL, nil, jsonrpc2.WithIDGenerator(func() *jsonrpc2.ID { return jsonrpc2.Int64ID(1) }))
+
+			var n int
+			err := client.Call(context.Background(), "test", nil, &n)
+			if tt.Error == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+			} else if err == nil || err.Error() != tt.Error {
+				t.Errorf("expected error %q but got %v", tt.Error, err)
+			}
+		})
+	}
+}
+
+func TestServer_ServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	url, _ := StartHTTPServer(t)
+
+	tests := []struct {
+		Name   string
+		Method string
+		Type   string
+		Body   string
+		Status int
+		Output string
+	}{
+		{
+			Name:   "call",
+			Method: http.MethodPost,
+			Type:   "application/json",
+			Body:   `{"jsonrpc":"2.0","method":"add","params":[1,2],"id":1}`,
+			Status: http.StatusOK,
+			Output: `{"jsonrpc":"2.0","result":3,"id":1}`,
+		},
+		{
+			Name:   "batch",
+			Method: http.MethodPost,
+			Type:   "application/json",
+			Body:   `[{"jsonrpc":"2.0","method":"add","params":[1,2],"id":1}]`,
+			Status: http.StatusOK,
+			Output: `[{"jsonrpc":"2.0","result":3,"id":1}]`,
+		},
+		{
+			Name:   "notification",
+			Method: http.MethodPost,
+			Type:   "application/json",
+			Body:   `{"jsonrpc":"2.0","method":"count","params":1}`,
+			Status: http.StatusNoContent,
+		},
+		{
+			Name:   "notification-batch",
+			Method: http.MethodPost,
+			Type:   "application/json",
+			Body:   `[{"jsonrpc":"2.0","method":"count","params":1},{"jsonrpc":"2.0","method":"count","params":2}]`,
+			Status: http.StatusNoContent,
+		},
+		{
+			Name:   "parse-error",
+			Method: http.MethodPost,
+			Type:   "application/json",
+			Body:   `{"jsonrpc":"2.0",`,
+			Status: http.StatusBadRequest,
+			Output: `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`,
+		},
+		{
+			Name:   "invalid-request",
+			Method: http.MethodPost,
+			Type:   "application/json",
+			Body:   `{"jsonrpc":"1.0","method":"add","id":1}`,
+			Status: http.StatusBadRequest,
+			Output: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"Invalid version: \"jsonrpc\" must be exactly \"2.0\" but got \"1.0\""},"id":1}`,
+		},
+		{
+			Name:   "empty-batch",
+			Method: http.MethodPost,
+			Type:   "application/json",
+			Body:   `[]`,
+			Status: http.StatusBadRequest,
+			Output: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"batch must not be empty"},"id":null}`,
+		},
+		{
+			Name:   "partially-invalid-batch",
+			Method: http.MethodPost,
+			Type:   "application/json",
+			Body:   `[{"jsonrpc":"2.0","method":"add","params":[1,2],"id":1},1]`,
+			Status: http.StatusOK,
+			Output: `[{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"request must be an object"},"id":null},{"jsonrpc":"2.0","result":3,"id":1}]`,
+		},
+		{
+			Name:   "get",
+			Method: http.MethodGet,
+			Status: http.StatusMethodNotAllowed,
+		},
+		{
+			Name:   "text",
+			Method: http.MethodPost,
+			Type:   "text/plain",
+			Body:   `{"jsonrpc":"2.0","method":"add","params":[1,2],"id":1}`,
+			Status: http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.Method, url, strings.NewReader(tt.Body))
+			if err != nil {
+				t.Fatalf("failed to create request: %s", err)
+			}
+			if tt.Type != "" {
+				req.Header.Set("Content-Type", tt.Type)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("failed to send request: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.Status {
+				t.Errorf("unexpected status: want=%d got=%d", tt.Status, resp.StatusCode)
+			}
+
+			if tt.Output == "" {
+				return
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to read response: %s", err)
+			}
+			if got := strings.TrimSpace(string(body)); got != tt.Output {
+				t.Errorf("unexpected response:\nwant: %s\n got: %s", tt.Output, got)
+			}
+		})
+	}
+}
+
+func TestServer_ServeHTTP_maxRequestSize(t *testing.T) {
+	t.Parallel()
+
+	server := jsonrpc2.NewServer(jsonrpc2.WithMaxRequestSize(64))
+	server.On("echo", jsonrpc2.Call(func(ctx context.Context, s string) (string, error) {
+		return s, nil
+	}))
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	tests := []struct {
+		Name   string
+		Body   string
+		Status int
+		Output string
+	}{
+		{
+			Name:   "small",
+			Body:   `{"jsonrpc":"2.0","method":"echo","params":"hi","id":1}`,
+			Status: http.StatusOK,
+			Output: `{"jsonrpc":"2.0","result":"hi","id":1}`,
+		},
+		{
+			Name:   "large",
+			Body:   `{"jsonrpc":"2.0","method":"echo","params":"` + strings.Repeat("x", 64) + `","id":1}`,
+			Status: http.StatusRequestEntityTooLarge,
+			Output: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"request body must not be larger than 64 bytes"},"id":null}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			resp, err := http.Post(ts.URL, "application/json", strings.NewReader(tt.Body))
+			if err != nil {
+				t.Fatalf("failed to send request: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.Status {
+				t.Errorf("unexpected status: want=%d got=%d", tt.Status, resp.StatusCode)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to read response: %s", err)
+			}
+			if got := strings.TrimSpace(string(body)); got != tt.Output {
+				t.Errorf("unexpected response:\nwant: %s\n got: %s", tt.Output, got)
+			}
+		})
+	}
+}
+
+func TestWithMaxRequestSize_invalid(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic for non-positive size")
+		}
+	}()
+	jsonrpc2.WithMaxRequestSize(0)
+}
@@ -2,9 +2,14 @@ package jsonrpc2
 
 import (
 	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"strconv"
+	"sync/atomic"
+	"time"
 
 	"github.com/goccy/go-json"
 )
@@ -99,3 +104,75 @@ func (id *ID) UnmarshalJSON(data []byte) error {
 
 	return fmt.Errorf("%w but got %q", ErrInvalidIDType, string(data))
 }
+
+// IDGenerator generates IDs for requests that clients send.
+//
+// It is called concurrently, and must not return the same ID while the previous request with it is pending.
+// It also must not return nil or `NullID`.
+type IDGenerator func() *ID
+
+// WithIDGenerator specifies the generator of request IDs.
+// If this option is not specified, `SequentialIDGenerator` is used.
+func WithIDGenerator(g IDGenerator) ClientOption {
+	return func(c *clientConfig) {
+		c.idGenerator = g
+	}
+}
+
+// SequentialIDGenerator returns an IDGenerator that generates integer IDs from 0.
+func SequentialIDGenerator() IDGenerator {
+	var next atomic.Int64
+	return func() *ID {
+		return Int64ID(next.Add(1) - 1)
+	}
+}
+
+// PrefixIDGenerator returns an IDGenerator that generates string IDs like "prefix0", "prefix1", ...
+func PrefixIDGenerator(prefix string) IDGenerator {
+	var next atomic.Int64
+	return func() *ID {
+		return StringID(prefix + strconv.FormatInt(next.Add(1)-1, 10))
+	}
+}
+
+// UUIDv4Generator returns an IDGenerator that generates random UUID version 4 strings.
+func UUIDv4Generator() IDGenerator {
+	return func() *ID {
+		var u [16]byte
+		rand.Read(u[:])
+		return StringID(formatUUID(u, 4))
+	}
+}
+
+// UUIDv7Generator returns an IDGenerator that generates UUID version 7 strings.
+// The IDs are ordered by the time they are generated in milliseconds.
+func UUIDv7Generator() IDGenerator {
+	return func() *ID {
+		var u [16]byte
+		rand.Read(u[6:])
+
+		var ts [8]byte
+		binary.BigEndian.PutUint64(ts[:], uint64(time.Now().UnixMilli()))
+		copy(u[:6], ts[2:])
+
+		return StringID(formatUUID(u, 7))
+	}
+}
+
+// formatUUID sets version and variant bits to `u`, and formats it as a string.
+func formatUUID(u [16]byte, version byte) string {
+	u[6] = u[6]&0x0f | version<<4
+	u[8] = u[8]&0x3f | 0x80
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
@@ -2,6 +2,7 @@ package jsonrpc2_test
 
 import (
 	"encoding/json"
+	"regexp"
 	"testing"
 
 	"github.com/macrat/go-jsonrpc2"
@@ -105,3 +106,43 @@ func BenchmarkID_String(b *testing.B) {
 		_ = str.String()
 	}
 }
+
+func TestIDGenerator(t *testing.T) {
+	t.Parallel()
+
+	seq := jsonrpc2.SequentialIDGenerator()
+	for i := int64(0); i < 3; i++ {
+		if raw := seq().Raw(); raw != i {
+			t.Errorf("unexpected sequential ID: want=%d got=%v", i, raw)
+		}
+	}
+
+	prefix := jsonrpc2.PrefixIDGenerator("req-")
+	for _, want := range []string{"req-0", "req-1", "req-2"} {
+		if raw := prefix().Raw(); raw != want {
+			t.Errorf("unexpected prefix ID: want=%q got=%v", want, raw)
+		}
+	}
+
+	uuids := []struct {
+		Name      string
+		Generator jsonrpc2.IDGenerator
+		Pattern   *regexp.Regexp
+	}{
+		{"v4", jsonrpc2.UUIDv4Generator(), regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)},
+		{"v7", jsonrpc2.UUIDv7Generator(), regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)},
+	}
+	for _, tt := range uuids {
+		seen := make(map[string]bool)
+		for i := 0; i < 100; i++ {
+			s, ok := tt.Generator().Raw().(string)
+			if !ok || !tt.Pattern.MatchString(s) {
+				t.Fatalf("%s: unexpected UUID: %v", tt.Name, s)
+			}
+			if seen[s] {
+				t.Fatalf("%s: duplicated UUID: %s", tt.Name, s)
+			}
+			seen[s] = true
+		}
+	}
+}
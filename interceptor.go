@@ -0,0 +1,168 @@
+package jsonrpc2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/goccy/go-json"
+)
+
+var (
+	ErrNoResponse = errors.New("jsonrpc2: no response for the request")
+)
+
+// Invoker sends a request and returns the response.
+//
+// The response is nil if the request is a notification.
+type Invoker func(ctx context.Context, req *Request[any]) (*Response[json.RawMessage], error)
+
+// Interceptor intercepts outgoing requests of `Client.Call` and `Client.Notify`.
+//
+// An interceptor can modify the request before calling `next`, and inspect the response after that.
+// It can also return a synthetic response without calling `next` to short-circuit the request.
+//
+// The `Params` of the request is the value passed to `Client.Call` or `Client.Notify` as-is.
+type Interceptor func(ctx context.Context, req *Request[any], next Invoker) (*Response[json.RawMessage], error)
+
+// BatchInvoker sends requests at once and returns the responses.
+//
+// The responses are in the same order as the requests, and the response is nil for notifications.
+type BatchInvoker func(ctx context.Context, reqs []*Request[any]) ([]*Response[json.RawMessage], error)
+
+// BatchInterceptor intercepts outgoing requests of `Client.Batch`.
+//
+// It works the same as `Interceptor`, but for batch requests.
+type BatchInterceptor func(ctx context.Context, reqs []*Request[any], next BatchInvoker) ([]*Response[json.RawMessage], error)
+
+// WithInterceptor adds interceptors for `Call` and `Notify`.
+// The first interceptor is the outermost one.
+func WithInterceptor(is ...Interceptor) ClientOption {
+	return func(c *clientConfig) {
+		c.interceptors = append(c.interceptors, is...)
+	}
+}
+
+// WithBatchInterceptor adds interceptors for `Batch`.
+// The first interceptor is the outermost one.
+func WithBatchInterceptor(is ...BatchInterceptor) ClientOption {
+	return func(c *clientConfig) {
+		c.batchInterceptors = append(c.batchInterceptors, is...)
+	}
+}
+
+// caller implements the common part of clients: building requests, applying interceptors, and reading responses.
+type caller struct {
+	newID       IDGenerator
+	invoke      Invoker
+	invokeBatch BatchInvoker
+}
+
+// init sets up the caller with transport-specific functions.
+func (c *caller) init(conf clientConfig, invoke Invoker, invokeBatch BatchInvoker) {
+	for i := len(conf.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := conf.interceptors[i], invoke
+		invoke = func(ctx context.Context, req *Request[any]) (*Response[json.RawMessage], error) {
+			return interceptor(ctx, req, next)
+		}
+	}
+
+	for i := len(conf.batchInterceptors) - 1; i >= 0; i-- {
+		interceptor, next := conf.batchInterceptors[i], invokeBatch
+		invokeBatch = func(ctx context.Context, reqs []*Request[any]) ([]*Response[json.RawMessage], error) {
+			return interceptor(ctx, reqs, next)
+		}
+	}
+
+	c.newID = conf.idGenerator
+	c.invoke = invoke
+	c.invokeBatch = invokeBatch
+}
+
+func (c *caller) call(ctx context.Context, name string, params any, result any) error {
+	res, err := c.invoke(ctx, &Request[any]{
+		Jsonrpc: VersionValue,
+		Method:  name,
+		Params:  params,
+		ID:      c.newID(),
+	})
+	if err != nil {
+		return err
+	}
+	if res == nil {
+		return ErrNoResponse
+	}
+
+	if res.Error != nil {
+		return res.Error
+	}
+
+	return json.Unmarshal(res.Result, result)
+}
+
+func (c *caller) notify(ctx context.Context, name string, params any) error {
+	res, err := c.invoke(ctx, &Request[any]{
+		Jsonrpc: VersionValue,
+		Method:  name,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	if res != nil && res.Error != nil {
+		return res.Error
+	}
+
+	return nil
+}
+
+func (c *caller) batch(ctx context.Context, reqs []BatchRequest) ([]*BatchResponse, error) {
+	rs := make([]*Request[any], len(reqs))
+	for i, r := range reqs {
+		rs[i] = &Request[any]{
+			Jsonrpc: VersionValue,
+			Method:  r.Method,
+			Params:  r.Params,
+		}
+		if !r.IsNotify {
+			rs[i].ID = c.newID()
+		}
+	}
+
+	res, err := c.invokeBatch(ctx, rs)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) != len(reqs) {
+		return nil, fmt.Errorf("jsonrpc2: number of responses does not match: want %d but got %d", len(reqs), len(res))
+	}
+
+	resps := make([]*BatchResponse, len(reqs))
+	for i, r := range reqs {
+		if r.IsNotify {
+			continue
+		}
+		if res[i] == nil {
+			return nil, fmt.Errorf("%w: %s", ErrNoResponse, r.Method)
+		}
+
+		resps[i] = &BatchResponse{
+			Method: r.Method,
+			Params: r.Params,
+			Result: res[i].Result,
+			Error:  res[i].Error,
+		}
+	}
+
+	return resps, nil
+}
+
+// wireRequest makes a request to send.
+// The params is always included in the request even if it is nil, for compatibility with servers that require it.
+func wireRequest(r *Request[any]) Request[any] {
+	w := *r
+	params := r.Params
+	w.Params = &params
+	return w
+}
@@ -0,0 +1,189 @@
+package jsonrpc2_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/google/go-cmp/cmp"
+	"github.com/macrat/go-jsonrpc2"
+)
+
+type AuthParams struct {
+	Token  string `json:"token"`
+	Params any    `json:"params"`
+}
+
+const FlakyErrorCode jsonrpc2.ErrorCode = -32001
+
+func NewInterceptorTestServer() (*jsonrpc2.Server, *int64) {
+	var flakyCount int64
+
+	server := jsonrpc2.NewServer()
+
+	server.On("whoami", jsonrpc2.Call(func(ctx context.Context, p AuthParams) (string, error) {
+		return p.Token, nil
+	}))
+
+	server.On("flaky", jsonrpc2.Call(func(ctx context.Context, _ AuthParams) (string, error) {
+		if atomic.AddInt64(&flakyCount, 1) < 3 {
+			return "", jsonrpc2.Error{Code: FlakyErrorCode, Message: "try again"}
+		}
+		return "ok", nil
+	}))
+
+	return server, &flakyCount
+}
+
+// AuthInterceptor wraps params with an auth token.
+func AuthInterceptor(token string) jsonrpc2.Interceptor {
+	return func(ctx context.Context, req *jsonrpc2.Request[any], next jsonrpc2.Invoker) (*jsonrpc2.Response[json.RawMessage], error) {
+		req.Params = AuthParams{Token: token, Params: req.Params}
+		return next(ctx, req)
+	}
+}
+
+// RetryInterceptor retries requests that failed with FlakyErrorCode.
+func RetryInterceptor(ctx context.Context, req *jsonrpc2.Request[any], next jsonrpc2.Invoker) (*jsonrpc2.Response[json.RawMessage], error) {
+	for {
+		res, err := next(ctx, req)
+		if err != nil || res == nil || res.Error == nil || res.Error.Code != FlakyErrorCode {
+			return res, err
+		}
+	}
+}
+
+// CacheInterceptor returns a synthetic response for "cached" method.
+func CacheInterceptor(ctx context.Context, req *jsonrpc2.Request[any], next jsonrpc2.Invoker) (*jsonrpc2.Response[json.RawMessage], error) {
+	if req.Method == "cached" {
+		return jsonrpc2.NewSuccessResponse(req.ID, json.RawMessage(`"from cache"`)), nil
+	}
+	return next(ctx, req)
+}
+
+func TestInterceptor(t *testing.T) {
+	t.Parallel()
+
+	server, flakyCount := NewInterceptorTestServer()
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	cli, srv := BiDirectionalPipe(t)
+	defer cli.Close()
+	go server.ServeForOne(srv)
+
+	var observed []string
+	observe := func(ctx context.Context, req *jsonrpc2.Request[any], next jsonrpc2.Invoker) (*jsonrpc2.Response[json.RawMessage], error) {
+		res, err := next(ctx, req)
+		if res != nil && res.Error != nil {
+			observed = append(observed, req.Method+":"+res.Error.Message)
+		} else {
+			observed = append(observed, req.Method+":ok")
+		}
+		return res, err
+	}
+
+	opts := []jsonrpc2.ClientOption{
+		jsonrpc2.WithInterceptor(observe, CacheInterceptor, RetryInterceptor),
+		jsonrpc2.WithInterceptor(AuthInterceptor("secret")),
+	}
+
+	clients := []struct {
+		Name   string
+		Client interface {
+			Call(context.Context, string, any, any) error
+		}
+	}{
+		{"stream", jsonrpc2.NewClient(cli, opts...)},
+		{"http", jsonrpc2.NewHTTPClient(ts.URL, nil, opts...)},
+	}
+
+	for _, c := range clients {
+		atomic.StoreInt64(flakyCount, 0)
+		observed = nil
+
+		ctx := context.Background()
+
+		var s string
+		if err := c.Client.Call(ctx, "whoami", nil, &s); err != nil {
+			t.Fatalf("%s: failed to call whoami: %s", c.Name, err)
+		} else if s != "secret" {
+			t.Errorf("%s: unexpected result of whoami: %q", c.Name, s)
+		}
+
+		if err := c.Client.Call(ctx, "flaky", nil, &s); err != nil {
+			t.Fatalf("%s: failed to call flaky: %s", c.Name, err)
+		} else if s != "ok" {
+			t.Errorf("%s: unexpected result of flaky: %q", c.Name, s)
+		}
+		if n := atomic.LoadInt64(flakyCount); n != 3 {
+			t.Errorf("%s: unexpected number of flaky calls: %d", c.Name, n)
+		}
+
+		if err := c.Client.Call(ctx, "cached", nil, &s); err != nil {
+			t.Fatalf("%s: failed to call cached: %s", c.Name, err)
+		} else if s != "from cache" {
+			t.Errorf("%s: unexpected result of cached: %q", c.Name, s)
+		}
+
+		if diff := cmp.Diff([]string{"whoami:ok", "flaky:ok", "cached:ok"}, observed); diff != "" {
+			t.Errorf("%s: unexpected observed responses:\n%s", c.Name, diff)
+		}
+	}
+}
+
+func TestBatchInterceptor(t *testing.T) {
+	t.Parallel()
+
+	server, _ := NewInterceptorTestServer()
+
+	cli, srv := BiDirectionalPipe(t)
+	defer cli.Close()
+	go server.ServeForOne(srv)
+
+	var sent int
+	client := jsonrpc2.NewClient(cli, jsonrpc2.WithBatchInterceptor(func(ctx context.Context, reqs []*jsonrpc2.Request[any], next jsonrpc2.BatchInvoker) ([]*jsonrpc2.Response[json.RawMessage], error) {
+		for _, r := range reqs {
+			r.Params = AuthParams{Token: r.Method, Params: r.Params}
+		}
+		sent += len(reqs)
+		return next(ctx, reqs)
+	}))
+	defer client.Close()
+
+	res, err := client.Batch(context.Background(), []jsonrpc2.BatchRequest{
+		{Method: "whoami", Params: 1},
+		{Method: "whoami", Params: 2, IsNotify: true},
+	})
+	if err != nil {
+		t.Fatalf("failed to call batch: %s", err)
+	}
+
+	expected := []*jsonrpc2.BatchResponse{
+		{Method: "whoami", Params: 1, Result: json.RawMessage(`"whoami"`)},
+		nil,
+	}
+	if diff := cmp.Diff(expected, res); diff != "" {
+		t.Errorf("unexpected response:\n%s", diff)
+	}
+	if sent != 2 {
+		t.Errorf("unexpected number of sent requests: %d", sent)
+	}
+
+	// The short-circuited client never communicates with the server.
+	idle, _ := BiDirectionalPipe(t)
+	defer idle.Close()
+
+	shortCircuit := jsonrpc2.NewClient(idle, jsonrpc2.WithBatchInterceptor(func(ctx context.Context, reqs []*jsonrpc2.Request[any], next jsonrpc2.BatchInvoker) ([]*jsonrpc2.Response[json.RawMessage], error) {
+		return nil, errors.New("batch is disabled")
+	}))
+	defer shortCircuit.Close()
+
+	if _, err := shortCircuit.Batch(context.Background(), []jsonrpc2.BatchRequest{{Method: "whoami"}}); err == nil || err.Error() != "batch is disabled" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package jsonrpc2
 
 import (
+	"context"
 	"io"
 	"net"
 )
@@ -8,11 +9,46 @@ import (
 // Listener is an interface for accepting connections.
 //
 // This interface is used for `Server.Serve` method.
+// The connections returned by Accept may implement `AcceptedConn` to let the server close them and tell handlers about them.
 type Listener interface {
 	Accept() (io.ReadWriter, error)
 	Close() error
 }
 
+// AcceptedConn is a connection that is accepted by a Listener.
+//
+// All listeners in this package return connections that implement this interface.
+// Handlers can get the connection by `ConnFromContext`.
+type AcceptedConn interface {
+	io.ReadWriteCloser
+
+	// LocalAddr returns the local network address.
+	LocalAddr() net.Addr
+
+	// RemoteAddr returns the remote network address.
+	RemoteAddr() net.Addr
+}
+
+type connKey struct{}
+
+// withConn returns a context that has the connection if it is an AcceptedConn.
+func withConn(ctx context.Context, rw io.ReadWriter) context.Context {
+	if conn, ok := rw.(AcceptedConn); ok {
+		return context.WithValue(ctx, connKey{}, conn)
+	}
+	return ctx
+}
+
+// ConnFromContext returns the connection that the request came from.
+//
+// It returns false if the request did not come from an `AcceptedConn`,
+// such as requests via `Server.ServeHTTP`.
+// The connection may also implement net.Conn to set deadlines.
+func ConnFromContext(ctx context.Context) (AcceptedConn, bool) {
+	conn, ok := ctx.Value(connKey{}).(AcceptedConn)
+	return conn, ok
+}
+
 type netListener struct {
 	listener net.Listener
 }
@@ -22,6 +58,9 @@ func (l *netListener) Accept() (io.ReadWriter, error) {
 	if err != nil {
 		return nil, err
 	}
+	if uc, ok := conn.(*net.UnixConn); ok {
+		return newUnixConn(uc), nil
+	}
 	return conn, nil
 }
 
@@ -29,20 +68,34 @@ func (l *netListener) Close() error {
 	return l.listener.Close()
 }
 
+// Addr returns the address of the listener.
+func (l *netListener) Addr() net.Addr {
+	return l.listener.Addr()
+}
+
+// NewListener creates a new Listener from net.Listener, such as the one created by tls.Listen.
+//
+// The accepted connections are net.Conn, that implement `AcceptedConn`.
+func NewListener(l net.Listener) Listener {
+	return &netListener{listener: l}
+}
+
 // NewTCPListener creates a new Listener for TCP connections.
 func NewTCPListener(addr string) (Listener, error) {
 	listener, err := net.Listen("tcp", addr)
 	if err != nil {
 		return nil, err
 	}
-	return &netListener{listener: listener}, nil
+	return NewListener(listener), nil
 }
 
 // NewUnixListener creates a new Listener for Unix domain socket connections.
+//
+// On Linux, handlers can get the credentials of the client process by `PeerCredFromContext`.
 func NewUnixListener(addr string) (Listener, error) {
 	listener, err := net.Listen("unix", addr)
 	if err != nil {
 		return nil, err
 	}
-	return &netListener{listener: listener}, nil
+	return NewListener(listener), nil
 }
@@ -0,0 +1,70 @@
+package jsonrpc2_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/macrat/go-jsonrpc2"
+)
+
+func TestNewListener(t *testing.T) {
+	t.Parallel()
+
+	nl, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+
+	server := jsonrpc2.NewServer()
+	server.On("whoami", jsonrpc2.Call(func(ctx context.Context, _ any) (string, error) {
+		conn, ok := jsonrpc2.ConnFromContext(ctx)
+		if !ok {
+			return "", jsonrpc2.ErrInternalError
+		}
+		return conn.RemoteAddr().String(), nil
+	}))
+
+	go server.Serve(jsonrpc2.NewListener(nl))
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", nl.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %s", err)
+	}
+	defer conn.Close()
+
+	client := jsonrpc2.NewClient(conn)
+	defer client.Close()
+
+	var addr string
+	if err := client.Call(context.Background(), "whoami", nil, &addr); err != nil {
+		t.Fatalf("failed to call: %s", err)
+	}
+	if addr != conn.LocalAddr().String() {
+		t.Errorf("unexpected remote address: expected %s but got %s", conn.LocalAddr(), addr)
+	}
+
+	// The server closes the connection when the client stops sending.
+	conn.(*net.TCPConn).CloseWrite()
+
+	select {
+	case <-client.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the server did not close the connection")
+	}
+
+	if err, ok := client.Err().(*jsonrpc2.ConnectionError); !ok || err.Err != io.EOF {
+		t.Errorf("unexpected error: %v", client.Err())
+	}
+}
+
+func TestConnFromContext(t *testing.T) {
+	t.Parallel()
+
+	if _, ok := jsonrpc2.ConnFromContext(context.Background()); ok {
+		t.Errorf("context without connection should return false")
+	}
+}
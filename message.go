@@ -1,6 +1,7 @@
 package jsonrpc2
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 
@@ -114,6 +115,13 @@ const (
 	MethodNotFoundCode ErrorCode = -32601
 	InvalidParamsCode  ErrorCode = -32602
 	InternalErrorCode  ErrorCode = -32603
+
+	// RequestCancelledCode is an error code for requests that are cancelled by the client.
+	// This code is defined by Language Server Protocol.
+	RequestCancelledCode ErrorCode = -32800
+
+	// ServerBusyCode is an error code for requests that are rejected because the server has too many requests to handle.
+	ServerBusyCode ErrorCode = -32000
 )
 
 var (
@@ -122,6 +130,9 @@ var (
 	ErrMethodNotFound = Error{Code: MethodNotFoundCode, Message: "Method not found"}
 	ErrInvalidParams  = Error{Code: InvalidParamsCode, Message: "Invalid params"}
 	ErrInternalError  = Error{Code: InternalErrorCode, Message: "Internal error"}
+
+	ErrRequestCancelled = Error{Code: RequestCancelledCode, Message: "Request cancelled"}
+	ErrServerBusy       = Error{Code: ServerBusyCode, Message: "Server busy"}
 )
 
 func (e ErrorCode) String() string {
@@ -136,6 +147,10 @@ func (e ErrorCode) String() string {
 		return fmt.Sprintf("Invalid params (%d)", InvalidParamsCode)
 	case InternalErrorCode:
 		return fmt.Sprintf("Internal error (%d)", InternalErrorCode)
+	case RequestCancelledCode:
+		return fmt.Sprintf("Request cancelled (%d)", RequestCancelledCode)
+	case ServerBusyCode:
+		return fmt.Sprintf("Server busy (%d)", ServerBusyCode)
 	}
 
 	if -32000 <= e && e <= -32099 {
@@ -153,7 +168,7 @@ type messageList[T any] struct {
 }
 
 func (l messageList[T]) MarshalJSON() ([]byte, error) {
-	if len(l.Messages) == 1 && l.IsBatch {
+	if len(l.Messages) == 1 && !l.IsBatch {
 		return json.Marshal(l.Messages[0])
 	}
 
@@ -183,3 +198,111 @@ func (l *messageList[T]) WriteTo(w io.Writer) (int64, error) {
 
 	return cw.count, nil
 }
+
+// parseErrorResponse is a pre-encoded response for malformed data.
+var parseErrorResponse, _ = json.Marshal(NewErrorResponse(NullID(), ErrParseError))
+
+// invalidRequest makes an error response for an invalid request.
+func invalidRequest(id *ID, detail string) Response[*any] {
+	err := ErrInvalidRequest
+	err.Data = detail
+	return Response[*any]{
+		Jsonrpc: VersionValue,
+		Error:   &err,
+		ID:      id,
+	}
+}
+
+// decodeRequest decodes a single request object in a frame.
+// If the request is invalid, it returns an error response for it.
+func decodeRequest(data json.RawMessage) (RawRequest, *Response[*any]) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		res := invalidRequest(nil, "request must be an object")
+		return RawRequest{}, &res
+	}
+
+	var m struct {
+		Jsonrpc json.RawMessage `json:"jsonrpc"`
+		Method  json.RawMessage `json:"method"`
+		Params  json.RawMessage `json:"params"`
+		ID      json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		res := invalidRequest(nil, err.Error())
+		return RawRequest{}, &res
+	}
+
+	var id *ID
+	if m.ID != nil {
+		id = new(ID)
+		if err := id.UnmarshalJSON(m.ID); err != nil {
+			res := invalidRequest(nil, err.Error())
+			return RawRequest{}, &res
+		}
+	}
+
+	var version Version
+	if m.Jsonrpc == nil {
+		res := invalidRequest(id, ErrInvalidVersion.Error())
+		return RawRequest{}, &res
+	} else if err := version.UnmarshalJSON(m.Jsonrpc); err != nil {
+		res := invalidRequest(id, err.Error())
+		return RawRequest{}, &res
+	}
+
+	var method string
+	if err := json.Unmarshal(m.Method, &method); m.Method == nil || err != nil || method == "" {
+		res := invalidRequest(id, `"method" must be a non-empty string`)
+		return RawRequest{}, &res
+	}
+
+	return RawRequest{
+		Jsonrpc: version,
+		Method:  method,
+		Params:  m.Params,
+		ID:      id,
+	}, nil
+}
+
+// decodeRequests decodes a frame from the client.
+//
+// It returns ErrParseError if the frame is not a valid JSON.
+// Invalid requests are returned as error responses instead of error,
+// so that valid requests in the same batch can still be handled.
+func decodeRequests(data []byte) (messageList[RawRequest], []Response[*any], error) {
+	if !json.Valid(data) {
+		return messageList[RawRequest]{}, nil, ErrParseError
+	}
+
+	data = bytes.TrimSpace(data)
+	if data[0] != '[' {
+		req, invalid := decodeRequest(data)
+		if invalid != nil {
+			return messageList[RawRequest]{}, []Response[*any]{*invalid}, nil
+		}
+		return messageList[RawRequest]{Messages: []RawRequest{req}}, nil, nil
+	}
+
+	var elems []json.RawMessage
+	if err := json.Unmarshal(data, &elems); err != nil {
+		return messageList[RawRequest]{}, nil, ErrParseError
+	}
+	if len(elems) == 0 {
+		// An empty batch is responded with a single error, not an array.
+		return messageList[RawRequest]{}, []Response[*any]{invalidRequest(nil, "batch must not be empty")}, nil
+	}
+
+	rs := messageList[RawRequest]{IsBatch: true}
+	var invalids []Response[*any]
+	for _, elem := range elems {
+		req, invalid := decodeRequest(elem)
+		if invalid != nil {
+			invalids = append(invalids, *invalid)
+		} else {
+			rs.Messages = append(rs.Messages, req)
+		}
+	}
+
+	return rs, invalids, nil
+}
@@ -0,0 +1,97 @@
+package jsonrpc2
+
+import (
+	"context"
+)
+
+// Caller is an interface to send requests.
+//
+// `Client`, `Conn`, `HTTPClient`, and `ReconnectingClient` implement this interface.
+type Caller interface {
+	Call(ctx context.Context, name string, params any, result any) error
+	Notify(ctx context.Context, name string, params any) error
+}
+
+// Method is a typed descriptor of a method that returns a result.
+//
+// It is useful to share the method name and types between the server and the client.
+//
+//	var Sum = jsonrpc2.NewMethod[[]int, int]("sum")
+//
+//	// server side
+//	Sum.Register(server, func(ctx context.Context, xs []int) (int, error) { ... })
+//
+//	// client side
+//	sum, err := Sum.Call(ctx, client, []int{1, 2, 3})
+type Method[I, O any] struct {
+	name string
+	opts []HandlerOption
+}
+
+// NewMethod creates a new Method descriptor.
+//
+// The `opts` parameter is used when the method is registered on a server.
+func NewMethod[I, O any](name string, opts ...HandlerOption) Method[I, O] {
+	return Method[I, O]{name, opts}
+}
+
+// Name returns the name of the method.
+func (m Method[I, O]) Name() string {
+	return m.name
+}
+
+// Handler creates a handler for the method with the implementation `f`.
+func (m Method[I, O]) Handler(f func(context.Context, I) (O, error)) Handler {
+	return Call(f, m.opts...)
+}
+
+// Register registers the implementation `f` of the method on the server.
+//
+// The `mws` parameter is the same as `Server.On`.
+func (m Method[I, O]) Register(s *Server, f func(context.Context, I) (O, error), mws ...Middleware) {
+	s.On(m.name, m.Handler(f), mws...)
+}
+
+// Call calls the method through `c`, and returns the result.
+func (m Method[I, O]) Call(ctx context.Context, c Caller, params I) (O, error) {
+	var result O
+	err := c.Call(ctx, m.name, params, &result)
+	return result, err
+}
+
+// NotificationMethod is a typed descriptor of a method that does not return a result.
+//
+// See `Method` for how to use it.
+type NotificationMethod[I any] struct {
+	name string
+	opts []HandlerOption
+}
+
+// NewNotificationMethod creates a new NotificationMethod descriptor.
+//
+// The `opts` parameter is used when the method is registered on a server.
+func NewNotificationMethod[I any](name string, opts ...HandlerOption) NotificationMethod[I] {
+	return NotificationMethod[I]{name, opts}
+}
+
+// Name returns the name of the method.
+func (m NotificationMethod[I]) Name() string {
+	return m.name
+}
+
+// Handler creates a handler for the method with the implementation `f`.
+func (m NotificationMethod[I]) Handler(f func(context.Context, I) error) Handler {
+	return Notify(f, m.opts...)
+}
+
+// Register registers the implementation `f` of the method on the server.
+//
+// The `mws` parameter is the same as `Server.On`.
+func (m NotificationMethod[I]) Register(s *Server, f func(context.Context, I) error, mws ...Middleware) {
+	s.On(m.name, m.Handler(f), mws...)
+}
+
+// Notify sends a notification of the method through `c`.
+func (m NotificationMethod[I]) Notify(ctx context.Context, c Caller, params I) error {
+	return c.Notify(ctx, m.name, params)
+}
@@ -0,0 +1,64 @@
+package jsonrpc2_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/macrat/go-jsonrpc2"
+)
+
+var (
+	_ jsonrpc2.Caller = (*jsonrpc2.Client)(nil)
+	_ jsonrpc2.Caller = (*jsonrpc2.Conn)(nil)
+	_ jsonrpc2.Caller = (*jsonrpc2.HTTPClient)(nil)
+	_ jsonrpc2.Caller = (*jsonrpc2.ReconnectingClient)(nil)
+)
+
+var (
+	SumMethod   = jsonrpc2.NewMethod[[]int, int]("sum", jsonrpc2.Description("Sum up the numbers."))
+	EventMethod = jsonrpc2.NewNotificationMethod[string]("event")
+)
+
+func TestMethod(t *testing.T) {
+	t.Parallel()
+
+	events := make(chan string, 1)
+
+	server := jsonrpc2.NewServer()
+	SumMethod.Register(server, func(ctx context.Context, xs []int) (int, error) {
+		sum := 0
+		for _, x := range xs {
+			sum += x
+		}
+		return sum, nil
+	})
+	EventMethod.Register(server, func(ctx context.Context, ev string) error {
+		events <- ev
+		return nil
+	})
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client := jsonrpc2.NewHTTPClient(ts.URL, nil)
+	ctx := context.Background()
+
+	if sum, err := SumMethod.Call(ctx, client, []int{1, 2, 3}); err != nil {
+		t.Fatalf("failed to call: %s", err)
+	} else if sum != 6 {
+		t.Errorf("unexpected result: %d", sum)
+	}
+
+	if err := EventMethod.Notify(ctx, client, "hello"); err != nil {
+		t.Fatalf("failed to notify: %s", err)
+	}
+	if ev := <-events; ev != "hello" {
+		t.Errorf("unexpected event: %q", ev)
+	}
+
+	doc := server.OpenRPC()
+	if doc.Methods[1].Name != SumMethod.Name() || doc.Methods[1].Description != "Sum up the numbers." {
+		t.Errorf("unexpected OpenRPC method: %+v", doc.Methods[1])
+	}
+}
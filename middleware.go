@@ -0,0 +1,96 @@
+package jsonrpc2
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// HandlerFunc is an adapter to use a function as a Handler.
+type HandlerFunc func(context.Context, RawRequest) (any, error)
+
+// ServeJSONRPC2 implements the Handler interface.
+func (f HandlerFunc) ServeJSONRPC2(ctx context.Context, r RawRequest) (any, error) {
+	return f(ctx, r)
+}
+
+// Middleware wraps a Handler to add extra behavior, such as logging, authentication, or metrics.
+//
+// A middleware can read the request before calling the next handler, and the result and error after that.
+//
+// Please use `WithMiddleware` to apply middlewares to all methods, or `Server.On` to apply them to a specific method.
+type Middleware func(Handler) Handler
+
+// applyMiddlewares wraps `h` with `mws`.
+// The first middleware is the outermost one.
+func applyMiddlewares(h Handler, mws []Middleware) Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// Recover is a middleware that recovers from panics in handlers.
+// A panicked request is responded with `ErrInternalError`.
+func Recover() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, r RawRequest) (result any, err error) {
+			defer func() {
+				if p := recover(); p != nil {
+					result = nil
+					err = ErrInternalError
+				}
+			}()
+
+			return next.ServeJSONRPC2(ctx, r)
+		})
+	}
+}
+
+// Timeout is a middleware that cancels the context of handlers after the given duration.
+//
+// Handlers have to check the context to stop their work.
+func Timeout(d time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, r RawRequest) (any, error) {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+
+			return next.ServeJSONRPC2(ctx, r)
+		})
+	}
+}
+
+// Logger is a middleware that logs each request with its method, ID, duration, and error.
+// If `logger` is nil, slog.Default() is used.
+//
+// Successful requests are logged at Info level, and failed requests are logged at Error level.
+func Logger(logger *slog.Logger) Middleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, r RawRequest) (any, error) {
+			start := time.Now()
+			result, err := next.ServeJSONRPC2(ctx, r)
+
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.Duration("duration", time.Since(start)),
+			}
+			if r.ID != nil {
+				attrs = append(attrs, slog.String("id", r.ID.String()))
+			}
+
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+				logger.LogAttrs(ctx, slog.LevelError, "jsonrpc2: request failed", attrs...)
+			} else {
+				logger.LogAttrs(ctx, slog.LevelInfo, "jsonrpc2: request handled", attrs...)
+			}
+
+			return result, err
+		})
+	}
+}
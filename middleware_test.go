@@ -0,0 +1,143 @@
+package jsonrpc2_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/google/go-cmp/cmp"
+	"github.com/macrat/go-jsonrpc2"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var trace []string
+
+	record := func(name string) jsonrpc2.Middleware {
+		return func(next jsonrpc2.Handler) jsonrpc2.Handler {
+			return jsonrpc2.HandlerFunc(func(ctx context.Context, r jsonrpc2.RawRequest) (any, error) {
+				trace = append(trace, name+":before:"+r.Method)
+				result, err := next.ServeJSONRPC2(ctx, r)
+				trace = append(trace, name+":after:"+r.Method)
+				return result, err
+			})
+		}
+	}
+
+	double := func(next jsonrpc2.Handler) jsonrpc2.Handler {
+		return jsonrpc2.HandlerFunc(func(ctx context.Context, r jsonrpc2.RawRequest) (any, error) {
+			result, err := next.ServeJSONRPC2(ctx, r)
+			if n, ok := result.(int); ok {
+				return n * 2, err
+			}
+			return result, err
+		})
+	}
+
+	server := jsonrpc2.NewServer(jsonrpc2.WithMiddleware(record("global1"), record("global2")))
+
+	server.On("add", jsonrpc2.Call(func(ctx context.Context, xs []int) (int, error) {
+		trace = append(trace, "add")
+		return xs[0] + xs[1], nil
+	}), record("local"), double)
+
+	ctx := context.Background()
+
+	result, err := server.ServeJSONRPC2(ctx, jsonrpc2.RawRequest{Method: "add", Params: json.RawMessage("[1,2]")})
+	if err != nil {
+		t.Fatalf("failed to call add: %s", err)
+	}
+	if result != 6 {
+		t.Errorf("unexpected result: %v", result)
+	}
+
+	_, err = server.ServeJSONRPC2(ctx, jsonrpc2.RawRequest{Method: "notFound"})
+	if !errors.Is(err, jsonrpc2.ErrMethodNotFound) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"global1:before:add",
+		"global2:before:add",
+		"local:before:add",
+		"add",
+		"local:after:add",
+		"global2:after:add",
+		"global1:after:add",
+		"global1:before:notFound",
+		"global2:before:notFound",
+		"global2:after:notFound",
+		"global1:after:notFound",
+	}
+	if diff := cmp.Diff(expected, trace); diff != "" {
+		t.Errorf("unexpected trace:\n%s", diff)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	t.Parallel()
+
+	server := jsonrpc2.NewServer(jsonrpc2.WithMiddleware(jsonrpc2.Recover()))
+	server.On("panic", jsonrpc2.Call(func(ctx context.Context, _ any) (any, error) {
+		panic("oops")
+	}))
+
+	_, err := server.ServeJSONRPC2(context.Background(), jsonrpc2.RawRequest{Method: "panic", Params: json.RawMessage("null")})
+	if !errors.Is(err, jsonrpc2.ErrInternalError) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+
+	server := jsonrpc2.NewServer()
+	server.On("wait", jsonrpc2.Call(func(ctx context.Context, _ any) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}), jsonrpc2.Timeout(10*time.Millisecond))
+
+	_, err := server.ServeJSONRPC2(context.Background(), jsonrpc2.RawRequest{Method: "wait", Params: json.RawMessage("null")})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	server := jsonrpc2.NewServer(jsonrpc2.WithMiddleware(jsonrpc2.Logger(logger)))
+	server.On("echo", jsonrpc2.Call(func(ctx context.Context, s string) (string, error) {
+		return s, nil
+	}))
+
+	ctx := context.Background()
+
+	server.ServeJSONRPC2(ctx, jsonrpc2.RawRequest{Method: "echo", Params: json.RawMessage(`"hello"`), ID: jsonrpc2.Int64ID(1)})
+	server.ServeJSONRPC2(ctx, jsonrpc2.RawRequest{Method: "notFound", ID: jsonrpc2.StringID("x")})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected number of log lines: %q", buf.String())
+	}
+
+	for _, s := range []string{"level=INFO", "method=echo", "id=1", "duration="} {
+		if !strings.Contains(lines[0], s) {
+			t.Errorf("first line does not contain %q: %s", s, lines[0])
+		}
+	}
+	for _, s := range []string{"level=ERROR", "method=notFound", `id="\"x\""`, `error="Method not found (-32601)"`} {
+		if !strings.Contains(lines[1], s) {
+			t.Errorf("second line does not contain %q: %s", s, lines[1])
+		}
+	}
+}
@@ -0,0 +1,391 @@
+package jsonrpc2
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+const (
+	// DiscoverMethod is the method name that returns the OpenRPC document of the server.
+	// Handlers registered by `Server.On` with this name take precedence over the built-in one.
+	DiscoverMethod = "rpc.discover"
+
+	// OpenRPCVersion is the version of OpenRPC specification that `Server.OpenRPC` generates.
+	OpenRPCVersion = "1.2.6"
+)
+
+// OpenRPCDocument is an OpenRPC document that describes methods of a server.
+type OpenRPCDocument struct {
+	OpenRPC    string             `json:"openrpc"`
+	Info       OpenRPCInfo        `json:"info"`
+	Methods    []OpenRPCMethod    `json:"methods"`
+	Components *OpenRPCComponents `json:"components,omitempty"`
+}
+
+// OpenRPCInfo is the metadata of an OpenRPC document.
+type OpenRPCInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenRPCMethod describes a method in an OpenRPC document.
+type OpenRPCMethod struct {
+	Name           string                     `json:"name"`
+	Summary        string                     `json:"summary,omitempty"`
+	Description    string                     `json:"description,omitempty"`
+	Params         []OpenRPCContentDescriptor `json:"params"`
+	Result         *OpenRPCContentDescriptor  `json:"result,omitempty"`
+	Errors         []Error                    `json:"errors,omitempty"`
+	ParamStructure string                     `json:"paramStructure,omitempty"`
+
+	// RawParams is true if the whole params is bound to a single Go value, such as a slice.
+	// In this case, Params has only one descriptor, and the params should be sent as the value of it as-is.
+	RawParams bool `json:"x-raw-params,omitempty"`
+
+	// Notification is true if the method does not return a result, so it should be sent as a notification.
+	// Methods without this flag should be called even if Result is nil.
+	Notification bool `json:"x-notification,omitempty"`
+}
+
+// OpenRPCContentDescriptor describes a parameter or a result in an OpenRPC document.
+type OpenRPCContentDescriptor struct {
+	Name     string      `json:"name"`
+	Required bool        `json:"required,omitempty"`
+	Schema   *JSONSchema `json:"schema"`
+}
+
+// OpenRPCComponents holds reusable definitions in an OpenRPC document.
+type OpenRPCComponents struct {
+	Schemas map[string]*JSONSchema `json:"schemas,omitempty"`
+}
+
+// JSONSchema is a subset of JSON Schema that is used in OpenRPC documents.
+type JSONSchema struct {
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+}
+
+// WithOpenRPCInfo specifies the title and version of the server in the OpenRPC document.
+func WithOpenRPCInfo(title, version string) ServerOption {
+	return func(s *Server) {
+		s.openrpcInfo = OpenRPCInfo{Title: title, Version: version}
+	}
+}
+
+// Summary specifies a short summary of the method for the OpenRPC document.
+func Summary(summary string) HandlerOption {
+	return func(c *handlerConfig) {
+		c.summary = summary
+	}
+}
+
+// Description specifies a description of the method for the OpenRPC document.
+func Description(description string) HandlerOption {
+	return func(c *handlerConfig) {
+		c.description = description
+	}
+}
+
+// PossibleErrors specifies errors that the method may return, for the OpenRPC document.
+func PossibleErrors(errs ...Error) HandlerOption {
+	return func(c *handlerConfig) {
+		c.errors = append(c.errors, errs...)
+	}
+}
+
+// handlerSignature is the Go types of a handler, that is used to generate the OpenRPC document.
+type handlerSignature struct {
+	// params is the types of parameters.
+	// If multi is false, it has only one type that the whole params is bound to.
+	params []reflect.Type
+	multi  bool
+
+	// result is the type of the result, or nil if the method does not return a result.
+	result reflect.Type
+
+	conf handlerConfig
+}
+
+// signatureHandler is a handler that knows its signature.
+type signatureHandler interface {
+	Handler
+	signature() handlerSignature
+}
+
+// OpenRPC generates the OpenRPC document of the server.
+//
+// Methods are described from the types of handlers created by `Call`, `Notify`, `Call2`, `Server.Register`, and so on.
+// Handlers that implement `Handler` by themselves are listed without params and result.
+func (s *Server) OpenRPC() OpenRPCDocument {
+	s.hmu.RLock()
+	handlers := slices.Clone(s.handlers)
+	s.hmu.RUnlock()
+
+	doc := OpenRPCDocument{
+		OpenRPC: OpenRPCVersion,
+		Info:    s.openrpcInfo,
+		Methods: make([]OpenRPCMethod, 0, len(handlers)),
+	}
+
+	g := &schemaGenerator{
+		names: make(map[reflect.Type]string),
+		defs:  make(map[string]*JSONSchema),
+	}
+
+	for _, h := range handlers {
+		m := OpenRPCMethod{
+			Name:   h.name,
+			Params: []OpenRPCContentDescriptor{},
+		}
+		if h.sig != nil {
+			g.describe(&m, *h.sig)
+		} else {
+			// The result of unknown handlers can be any value.
+			m.Result = &OpenRPCContentDescriptor{Name: "result", Schema: &JSONSchema{}}
+		}
+		doc.Methods = append(doc.Methods, m)
+	}
+
+	if len(g.defs) > 0 {
+		doc.Components = &OpenRPCComponents{Schemas: g.defs}
+	}
+
+	return doc
+}
+
+// schemaGenerator generates JSON Schemas from Go types.
+// Named struct types are defined in the components, to support recursive types.
+type schemaGenerator struct {
+	names map[reflect.Type]string
+	defs  map[string]*JSONSchema
+}
+
+var (
+	timeType           = reflect.TypeFor[time.Time]()
+	jsonMarshalerType  = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType  = reflect.TypeFor[encoding.TextMarshaler]()
+	jsonRawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+func (g *schemaGenerator) describe(m *OpenRPCMethod, sig handlerSignature) {
+	m.Summary = sig.conf.summary
+	m.Description = sig.conf.description
+	m.Errors = sig.conf.errors
+
+	if sig.result != nil {
+		m.Result = &OpenRPCContentDescriptor{
+			Name:   "result",
+			Schema: g.schema(sig.result),
+		}
+	} else {
+		m.Notification = true
+	}
+
+	if sig.multi {
+		for i, t := range sig.params {
+			name := fmt.Sprintf("arg%d", i)
+			if sig.conf.paramNames != nil {
+				name = sig.conf.paramNames[i]
+			}
+			m.Params = append(m.Params, OpenRPCContentDescriptor{
+				Name:   name,
+				Schema: g.schema(t),
+			})
+		}
+		if sig.conf.paramNames != nil {
+			m.ParamStructure = "either"
+		} else {
+			m.ParamStructure = "by-position"
+		}
+		return
+	}
+
+	t := sig.params[0]
+	if t.Kind() != reflect.Struct || isCustomJSON(t) {
+		m.RawParams = true
+		m.Params = append(m.Params, OpenRPCContentDescriptor{
+			Name:     "params",
+			Required: true,
+			Schema:   g.schema(t),
+		})
+		return
+	}
+
+	// Struct params can be sent either by-name or by-position, as `unmarshalParams` does.
+	// Fields that cannot be sent by-position are listed after the positional fields, and then params must be sent by-name.
+	m.ParamStructure = "either"
+	fields := positionalFields(t)
+	bound := make(map[int]bool, len(fields))
+	for _, i := range fields {
+		bound[i] = true
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); bound[i] || !f.IsExported() || f.Anonymous || name == "-" {
+			continue
+		}
+		fields = append(fields, i)
+		m.ParamStructure = "by-name"
+	}
+
+	for _, i := range fields {
+		f := t.Field(i)
+		name, omitempty := jsonFieldName(f)
+		m.Params = append(m.Params, OpenRPCContentDescriptor{
+			Name:     name,
+			Required: !omitempty,
+			Schema:   g.schema(f.Type),
+		})
+	}
+}
+
+// jsonFieldName returns the name of the field in JSON, and whether the field has omitempty option.
+func jsonFieldName(f reflect.StructField) (name string, omitempty bool) {
+	name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		name = f.Name
+	}
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "omitempty" || opt == "omitzero" {
+			omitempty = true
+		}
+	}
+	return name, omitempty
+}
+
+// implements reports whether the type or the pointer to it implements the interface.
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+// isCustomJSON reports whether the type has its own JSON representation that cannot be inferred from its Go type.
+func isCustomJSON(t reflect.Type) bool {
+	return implements(t, jsonMarshalerType) || implements(t, textMarshalerType)
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) *JSONSchema {
+	switch {
+	case t == timeType:
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	case t == jsonRawMessageType:
+		return &JSONSchema{}
+	case implements(t, jsonMarshalerType):
+		return &JSONSchema{}
+	case implements(t, textMarshalerType):
+		return &JSONSchema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schema(t.Elem())
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &JSONSchema{Type: "string", Format: "byte"}
+		}
+		return &JSONSchema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Array:
+		n := t.Len()
+		return &JSONSchema{Type: "array", Items: g.schema(t.Elem()), MinItems: &n, MaxItems: &n}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return &JSONSchema{Ref: "#/components/schemas/" + g.define(t)}
+	default:
+		return &JSONSchema{}
+	}
+}
+
+// define registers a named type in the components, and returns the name of the definition.
+func (g *schemaGenerator) define(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	base := strings.Map(func(r rune) rune {
+		if r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, t.Name())
+
+	name := base
+	for i := 2; ; i++ {
+		if _, ok := g.defs[name]; !ok {
+			break
+		}
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+
+	g.names[t] = name
+	g.defs[name] = nil // reserve the name before generating, for recursive types.
+	g.defs[name] = g.structSchema(t)
+
+	return name
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) *JSONSchema {
+	s := &JSONSchema{
+		Type:       "object",
+		Properties: make(map[string]*JSONSchema),
+	}
+	g.addFields(s, t)
+	return s
+}
+
+// addFields adds the fields of the struct to the schema, flattening embedded structs like encoding/json.
+func (g *schemaGenerator) addFields(s *JSONSchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if tag == "-" {
+			continue
+		}
+
+		if f.Anonymous && tag == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(s, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		name, omitempty := jsonFieldName(f)
+		s.Properties[name] = g.schema(f.Type)
+		if !omitempty {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
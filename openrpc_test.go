@@ -0,0 +1,187 @@
+package jsonrpc2_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/google/go-cmp/cmp"
+	"github.com/macrat/go-jsonrpc2"
+)
+
+type User struct {
+	Name      string    `json:"name"`
+	Email     string    `json:"email,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	Friends   []*User   `json:"friends,omitempty"`
+}
+
+type GetUserParams struct {
+	ID      int  `json:"id"`
+	Verbose bool `json:"verbose,omitempty"`
+}
+
+func NewOpenRPCTestServer() *jsonrpc2.Server {
+	server := jsonrpc2.NewServer(jsonrpc2.WithOpenRPCInfo("test", "1.2.3"))
+
+	server.On("getUser", jsonrpc2.Call(func(ctx context.Context, p GetUserParams) (User, error) {
+		return User{Name: "alice"}, nil
+	}, jsonrpc2.Summary("Get a user"), jsonrpc2.Description("Get a user by ID."), jsonrpc2.PossibleErrors(jsonrpc2.Error{Code: 1, Message: "user not found"})))
+
+	server.On("sum", jsonrpc2.Call(func(ctx context.Context, xs []float64) (float64, error) {
+		return 0, nil
+	}))
+
+	server.On("log", jsonrpc2.Notify(func(ctx context.Context, msg string) error {
+		return nil
+	}))
+
+	server.On("repeat", jsonrpc2.Call2(func(ctx context.Context, s string, n int) (string, error) {
+		return s, nil
+	}, jsonrpc2.ParamNames("s", "n")))
+
+	server.On("raw", jsonrpc2.HandlerFunc(func(ctx context.Context, r jsonrpc2.RawRequest) (any, error) {
+		return nil, nil
+	}))
+
+	return server
+}
+
+func TestServer_OpenRPC(t *testing.T) {
+	t.Parallel()
+
+	doc := NewOpenRPCTestServer().OpenRPC()
+
+	expected := jsonrpc2.OpenRPCDocument{
+		OpenRPC: jsonrpc2.OpenRPCVersion,
+		Info:    jsonrpc2.OpenRPCInfo{Title: "test", Version: "1.2.3"},
+		Methods: []jsonrpc2.OpenRPCMethod{
+			{
+				Name:           "getUser",
+				Summary:        "Get a user",
+				Description:    "Get a user by ID.",
+				ParamStructure: "either",
+				Params: []jsonrpc2.OpenRPCContentDescriptor{
+					{Name: "id", Required: true, Schema: &jsonrpc2.JSONSchema{Type: "integer"}},
+					{Name: "verbose", Schema: &jsonrpc2.JSONSchema{Type: "boolean"}},
+				},
+				Result: &jsonrpc2.OpenRPCContentDescriptor{
+					Name:   "result",
+					Schema: &jsonrpc2.JSONSchema{Ref: "#/components/schemas/User"},
+				},
+				Errors: []jsonrpc2.Error{{Code: 1, Message: "user not found"}},
+			},
+			{
+				Name:         "log",
+				RawParams:    true,
+				Notification: true,
+				Params: []jsonrpc2.OpenRPCContentDescriptor{
+					{Name: "params", Required: true, Schema: &jsonrpc2.JSONSchema{Type: "string"}},
+				},
+			},
+			{
+				Name:   "raw",
+				Params: []jsonrpc2.OpenRPCContentDescriptor{},
+				Result: &jsonrpc2.OpenRPCContentDescriptor{
+					Name:   "result",
+					Schema: &jsonrpc2.JSONSchema{},
+				},
+			},
+			{
+				Name:           "repeat",
+				ParamStructure: "either",
+				Params: []jsonrpc2.OpenRPCContentDescriptor{
+					{Name: "s", Schema: &jsonrpc2.JSONSchema{Type: "string"}},
+					{Name: "n", Schema: &jsonrpc2.JSONSchema{Type: "integer"}},
+				},
+				Result: &jsonrpc2.OpenRPCContentDescriptor{
+					Name:   "result",
+					Schema: &jsonrpc2.JSONSchema{Type: "string"},
+				},
+			},
+			{
+				Name:      "sum",
+				RawParams: true,
+				Params: []jsonrpc2.OpenRPCContentDescriptor{
+					{Name: "params", Required: true, Schema: &jsonrpc2.JSONSchema{Type: "array", Items: &jsonrpc2.JSONSchema{Type: "number"}}},
+				},
+				Result: &jsonrpc2.OpenRPCContentDescriptor{
+					Name:   "result",
+					Schema: &jsonrpc2.JSONSchema{Type: "number"},
+				},
+			},
+		},
+		Components: &jsonrpc2.OpenRPCComponents{
+			Schemas: map[string]*jsonrpc2.JSONSchema{
+				"User": {
+					Type: "object",
+					Properties: map[string]*jsonrpc2.JSONSchema{
+						"name":      {Type: "string"},
+						"email":     {Type: "string"},
+						"createdAt": {Type: "string", Format: "date-time"},
+						"friends":   {Type: "array", Items: &jsonrpc2.JSONSchema{Ref: "#/components/schemas/User"}},
+					},
+					Required: []string{"name", "createdAt"},
+				},
+			},
+		},
+	}
+
+	if diff := cmp.Diff(expected, doc); diff != "" {
+		t.Errorf("unexpected document:\n%s", diff)
+	}
+}
+
+func TestServer_OpenRPC_position(t *testing.T) {
+	t.Parallel()
+
+	server := jsonrpc2.NewServer()
+	server.On("move", jsonrpc2.Call(func(ctx context.Context, p PositionedParams) (string, error) {
+		return "ok", nil
+	}))
+
+	expected := []jsonrpc2.OpenRPCMethod{
+		{
+			Name:           "move",
+			ParamStructure: "by-name",
+			Params: []jsonrpc2.OpenRPCContentDescriptor{
+				{Name: "name", Required: true, Schema: &jsonrpc2.JSONSchema{Type: "string"}},
+				{Name: "age", Required: true, Schema: &jsonrpc2.JSONSchema{Type: "integer"}},
+				{Name: "admin", Required: true, Schema: &jsonrpc2.JSONSchema{Type: "boolean"}},
+			},
+			Result: &jsonrpc2.OpenRPCContentDescriptor{
+				Name:   "result",
+				Schema: &jsonrpc2.JSONSchema{Type: "string"},
+			},
+		},
+	}
+
+	if diff := cmp.Diff(expected, server.OpenRPC().Methods); diff != "" {
+		t.Errorf("unexpected methods:\n%s", diff)
+	}
+}
+
+func TestServer_discover(t *testing.T) {
+	t.Parallel()
+
+	server := NewOpenRPCTestServer()
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	var got json.RawMessage
+	if err := jsonrpc2.NewHTTPClient(ts.URL, nil).Call(context.Background(), jsonrpc2.DiscoverMethod, nil, &got); err != nil {
+		t.Fatalf("failed to call %s: %s", jsonrpc2.DiscoverMethod, err)
+	}
+
+	want, err := json.Marshal(server.OpenRPC())
+	if err != nil {
+		t.Fatalf("failed to marshal document: %s", err)
+	}
+
+	if string(got) != string(want) {
+		t.Errorf("unexpected document:\nwant: %s\n got: %s", want, got)
+	}
+}
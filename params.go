@@ -0,0 +1,415 @@
+package jsonrpc2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+)
+
+var (
+	errParamsType    = errors.New("params must be an array or an object")
+	errTooManyParams = errors.New("too many params")
+	errByNameParams  = errors.New("params by-name is not supported by this method")
+)
+
+// ParamNames specifies the names of parameters for multi-argument handlers such as `Call2`.
+//
+// With this option, the handler accepts params by-name as well as by-position.
+// The number of names must be the same as the number of parameters.
+func ParamNames(names ...string) HandlerOption {
+	return func(c *handlerConfig) {
+		c.paramNames = names
+	}
+}
+
+// unmarshalParams unmarshals params into `v`.
+//
+// If `v` is a pointer to a struct and params is an array, the elements are bound to the fields by position.
+// See `positionalFields` for the order of the fields.
+// Params by-name are bound to the fields by the `json` tags as usual.
+func unmarshalParams(data json.RawMessage, v any) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '[' {
+		return json.Unmarshal(data, v)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return json.Unmarshal(data, v)
+	}
+	if _, ok := v.(json.Unmarshaler); ok {
+		return json.Unmarshal(data, v)
+	}
+
+	var arr []json.RawMessage
+	if err := json.Unmarshal(data, &arr); err != nil {
+		return err
+	}
+
+	fields := positionalFields(rv.Elem().Type())
+	if len(arr) > len(fields) {
+		return errTooManyParams
+	}
+
+	for i, raw := range arr {
+		if err := json.Unmarshal(raw, rv.Elem().Field(fields[i]).Addr().Interface()); err != nil {
+			return fmt.Errorf("params[%d]: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+var positionsCache sync.Map // map[reflect.Type]positionsEntry
+
+type positionsEntry struct {
+	fields []int
+	err    error
+}
+
+// positionalFields returns the indexes of fields of a struct that are bound to params by-position, in the order of positions.
+//
+// If some fields have `position=N` option in the `jsonrpc2` tag, only these fields are bound, in the order of N.
+// The positions must start from 0 and must not have gaps.
+//
+//	type MoveParams struct {
+//		X     int  `json:"x" jsonrpc2:"position=0"`
+//		Y     int  `json:"y" jsonrpc2:"position=1"`
+//		Force bool `json:"force"` // This field can be given only by-name.
+//	}
+//
+// Otherwise, all exported fields are bound in the order of the fields declaration.
+// In both cases, embedded fields and fields tagged `json:"-"` are not bound by-position.
+//
+// Tags are checked by checkPositions when the handler is created, so errors are ignored here.
+func positionalFields(t reflect.Type) []int {
+	fields, _ := structPositions(t)
+	return fields
+}
+
+func structPositions(t reflect.Type) ([]int, error) {
+	if e, ok := positionsCache.Load(t); ok {
+		return e.(positionsEntry).fields, e.(positionsEntry).err
+	}
+
+	fields, err := parsePositions(t)
+	positionsCache.Store(t, positionsEntry{fields, err})
+	return fields, err
+}
+
+func parsePositions(t reflect.Type) ([]int, error) {
+	var declared []int
+	tagged := make(map[int]int) // position -> field index
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		pos, ok, err := fieldPosition(f)
+		if err != nil {
+			return nil, fmt.Errorf("jsonrpc2: invalid position of %s.%s: %w", t, f.Name, err)
+		}
+
+		if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); !f.IsExported() || f.Anonymous || name == "-" {
+			if ok {
+				return nil, fmt.Errorf("jsonrpc2: position of %s.%s is given, but the field cannot be bound by-position", t, f.Name)
+			}
+			continue
+		}
+
+		if ok {
+			if j, dup := tagged[pos]; dup {
+				return nil, fmt.Errorf("jsonrpc2: position %d of %s is given to both %s and %s", pos, t, t.Field(j).Name, f.Name)
+			}
+			tagged[pos] = i
+		}
+		declared = append(declared, i)
+	}
+
+	if len(tagged) == 0 {
+		return declared, nil
+	}
+
+	fields := make([]int, len(tagged))
+	for pos := range fields {
+		i, ok := tagged[pos]
+		if !ok {
+			return nil, fmt.Errorf("jsonrpc2: position %d of %s is missing", pos, t)
+		}
+		fields[pos] = i
+	}
+	return fields, nil
+}
+
+// fieldPosition returns the value of the `position` option in the `jsonrpc2` tag of the field.
+func fieldPosition(f reflect.StructField) (pos int, ok bool, err error) {
+	for _, opt := range tagOptions(f.Tag.Get("jsonrpc2")) {
+		value, found := strings.CutPrefix(opt, "position=")
+		if !found {
+			continue
+		}
+		pos, err = strconv.Atoi(value)
+		if err != nil || pos < 0 {
+			return 0, false, fmt.Errorf("position must be a non-negative integer: %q", value)
+		}
+		return pos, true, nil
+	}
+	return 0, false, nil
+}
+
+// checkPositions checks that the `position` options of the struct type `t` are valid.
+func checkPositions(t reflect.Type) error {
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	_, err := structPositions(t)
+	return err
+}
+
+// checkParamsType checks that the tags of the params type `t` are valid.
+func checkParamsType(t reflect.Type) error {
+	if err := checkPositions(t); err != nil {
+		return err
+	}
+	return checkValidation(t)
+}
+
+// mustCheckParamsType is the same as checkParamsType but panics if the tags are invalid.
+// It is used by handler constructors to report invalid tags as early as possible.
+func mustCheckParamsType(ts ...reflect.Type) {
+	for _, t := range ts {
+		if err := checkParamsType(t); err != nil {
+			panic(err.Error())
+		}
+	}
+}
+
+// unmarshalArgs unmarshals params into multiple arguments.
+//
+// Params by-position are bound in order, and missing elements are left as zero values.
+// Params by-name are bound by `names`, and it is not allowed if `names` is nil.
+func unmarshalArgs(data json.RawMessage, names []string, args ...any) error {
+	data = bytes.TrimSpace(data)
+
+	switch {
+	case len(data) > 0 && data[0] == '[':
+		var arr []json.RawMessage
+		if err := json.Unmarshal(data, &arr); err != nil {
+			return err
+		}
+		if len(arr) > len(args) {
+			return errTooManyParams
+		}
+		for i, raw := range arr {
+			if err := json.Unmarshal(raw, args[i]); err != nil {
+				return fmt.Errorf("params[%d]: %w", i, err)
+			}
+		}
+		return nil
+
+	case len(data) > 0 && data[0] == '{':
+		if names == nil {
+			return errByNameParams
+		}
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return err
+		}
+		for i, name := range names {
+			if raw, ok := obj[name]; ok {
+				if err := json.Unmarshal(raw, args[i]); err != nil {
+					return fmt.Errorf("params.%s: %w", name, err)
+				}
+			}
+		}
+		return nil
+
+	default:
+		return errParamsType
+	}
+}
+
+func checkParamNames(conf handlerConfig, n int) {
+	if conf.paramNames != nil && len(conf.paramNames) != n {
+		panic(fmt.Sprintf("jsonrpc2: %d param names are given for a handler with %d parameters", len(conf.paramNames), n))
+	}
+}
+
+// Call2 creates a new JSON-RPC 2.0 handler for a method that takes 2 parameters and returns a result.
+//
+// The handler accepts params by-position like `[a, b]`.
+// Params by-name like `{"a": a, "b": b}` are also accepted if `ParamNames` option is given.
+func Call2[A, B, O any](f func(context.Context, A, B) (O, error), opts ...HandlerOption) Handler {
+	conf := newHandlerConfig(opts)
+	checkParamNames(conf, 2)
+	mustCheckParamsType(reflect.TypeFor[A](), reflect.TypeFor[B]())
+	return call2Handler[A, B, O]{f, conf}
+}
+
+type call2Handler[A, B, O any] struct {
+	f    func(context.Context, A, B) (O, error)
+	conf handlerConfig
+}
+
+func (h call2Handler[A, B, O]) signature() handlerSignature {
+	return handlerSignature{
+		params: []reflect.Type{reflect.TypeFor[A](), reflect.TypeFor[B]()},
+		multi:  true,
+		result: reflect.TypeFor[O](),
+		conf:   h.conf,
+	}
+}
+
+func (h call2Handler[A, B, O]) ServeJSONRPC2(ctx context.Context, r RawRequest) (any, error) {
+	var a A
+	var b B
+
+	if err := unmarshalArgs(r.Params, h.conf.paramNames, &a, &b); err != nil {
+		return nil, ErrInvalidParams
+	}
+	if isStrict(ctx, h.conf) {
+		if err := checkStrictArgs(r.Params, h.conf.paramNames, reflect.TypeFor[A](), reflect.TypeFor[B]()); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateParams(argPaths(r.Params, h.conf.paramNames, 2), &a, &b); err != nil {
+		return nil, err
+	}
+
+	return h.f(ctx, a, b)
+}
+
+// Call3 creates a new JSON-RPC 2.0 handler for a method that takes 3 parameters and returns a result.
+//
+// See `Call2` for how params are bound.
+func Call3[A, B, C, O any](f func(context.Context, A, B, C) (O, error), opts ...HandlerOption) Handler {
+	conf := newHandlerConfig(opts)
+	checkParamNames(conf, 3)
+	mustCheckParamsType(reflect.TypeFor[A](), reflect.TypeFor[B](), reflect.TypeFor[C]())
+	return call3Handler[A, B, C, O]{f, conf}
+}
+
+type call3Handler[A, B, C, O any] struct {
+	f    func(context.Context, A, B, C) (O, error)
+	conf handlerConfig
+}
+
+func (h call3Handler[A, B, C, O]) signature() handlerSignature {
+	return handlerSignature{
+		params: []reflect.Type{reflect.TypeFor[A](), reflect.TypeFor[B](), reflect.TypeFor[C]()},
+		multi:  true,
+		result: reflect.TypeFor[O](),
+		conf:   h.conf,
+	}
+}
+
+func (h call3Handler[A, B, C, O]) ServeJSONRPC2(ctx context.Context, r RawRequest) (any, error) {
+	var a A
+	var b B
+	var c C
+
+	if err := unmarshalArgs(r.Params, h.conf.paramNames, &a, &b, &c); err != nil {
+		return nil, ErrInvalidParams
+	}
+	if isStrict(ctx, h.conf) {
+		if err := checkStrictArgs(r.Params, h.conf.paramNames, reflect.TypeFor[A](), reflect.TypeFor[B](), reflect.TypeFor[C]()); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateParams(argPaths(r.Params, h.conf.paramNames, 3), &a, &b, &c); err != nil {
+		return nil, err
+	}
+
+	return h.f(ctx, a, b, c)
+}
+
+// Notify2 creates a new JSON-RPC 2.0 handler for a method that takes 2 parameters and does not return a result.
+//
+// See `Call2` for how params are bound.
+func Notify2[A, B any](f func(context.Context, A, B) error, opts ...HandlerOption) Handler {
+	conf := newHandlerConfig(opts)
+	checkParamNames(conf, 2)
+	mustCheckParamsType(reflect.TypeFor[A](), reflect.TypeFor[B]())
+	return notify2Handler[A, B]{f, conf}
+}
+
+type notify2Handler[A, B any] struct {
+	f    func(context.Context, A, B) error
+	conf handlerConfig
+}
+
+func (h notify2Handler[A, B]) signature() handlerSignature {
+	return handlerSignature{
+		params: []reflect.Type{reflect.TypeFor[A](), reflect.TypeFor[B]()},
+		multi:  true,
+		conf:   h.conf,
+	}
+}
+
+func (h notify2Handler[A, B]) ServeJSONRPC2(ctx context.Context, r RawRequest) (any, error) {
+	var a A
+	var b B
+
+	if err := unmarshalArgs(r.Params, h.conf.paramNames, &a, &b); err != nil {
+		return nil, ErrInvalidParams
+	}
+	if isStrict(ctx, h.conf) {
+		if err := checkStrictArgs(r.Params, h.conf.paramNames, reflect.TypeFor[A](), reflect.TypeFor[B]()); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateParams(argPaths(r.Params, h.conf.paramNames, 2), &a, &b); err != nil {
+		return nil, err
+	}
+
+	return nil, h.f(ctx, a, b)
+}
+
+// Notify3 creates a new JSON-RPC 2.0 handler for a method that takes 3 parameters and does not return a result.
+//
+// See `Call2` for how params are bound.
+func Notify3[A, B, C any](f func(context.Context, A, B, C) error, opts ...HandlerOption) Handler {
+	conf := newHandlerConfig(opts)
+	checkParamNames(conf, 3)
+	mustCheckParamsType(reflect.TypeFor[A](), reflect.TypeFor[B](), reflect.TypeFor[C]())
+	return notify3Handler[A, B, C]{f, conf}
+}
+
+type notify3Handler[A, B, C any] struct {
+	f    func(context.Context, A, B, C) error
+	conf handlerConfig
+}
+
+func (h notify3Handler[A, B, C]) signature() handlerSignature {
+	return handlerSignature{
+		params: []reflect.Type{reflect.TypeFor[A](), reflect.TypeFor[B](), reflect.TypeFor[C]()},
+		multi:  true,
+		conf:   h.conf,
+	}
+}
+
+func (h notify3Handler[A, B, C]) ServeJSONRPC2(ctx context.Context, r RawRequest) (any, error) {
+	var a A
+	var b B
+	var c C
+
+	if err := unmarshalArgs(r.Params, h.conf.paramNames, &a, &b, &c); err != nil {
+		return nil, ErrInvalidParams
+	}
+	if isStrict(ctx, h.conf) {
+		if err := checkStrictArgs(r.Params, h.conf.paramNames, reflect.TypeFor[A](), reflect.TypeFor[B](), reflect.TypeFor[C]()); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateParams(argPaths(r.Params, h.conf.paramNames, 3), &a, &b, &c); err != nil {
+		return nil, err
+	}
+
+	return nil, h.f(ctx, a, b, c)
+}
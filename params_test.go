@@ -0,0 +1,242 @@
+package jsonrpc2_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/macrat/go-jsonrpc2"
+)
+
+type ParamsTest struct {
+	Name    string
+	Handler jsonrpc2.Handler
+	Params  string
+	Output  string
+	Code    jsonrpc2.ErrorCode // Code is the expected error code, or 0 if no error is expected.
+}
+
+// RunParamsTests calls handlers with the params, and checks the output that is taken by `output` from the result.
+func RunParamsTests(t *testing.T, output func(result any) string, tests []ParamsTest) {
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			result, err := tt.Handler.ServeJSONRPC2(context.Background(), jsonrpc2.RawRequest{
+				Method: "test",
+				Params: json.RawMessage(tt.Params),
+			})
+
+			if tt.Code != 0 {
+				if e, ok := err.(jsonrpc2.Error); !ok || e.Code != tt.Code {
+					t.Errorf("expected error code %d but got %v", tt.Code, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got := output(result); got != tt.Output {
+				t.Errorf("unexpected output: expected %s but got %s", tt.Output, got)
+			}
+		})
+	}
+}
+
+func resultString(result any) string {
+	return fmt.Sprint(result)
+}
+
+func TestCall2(t *testing.T) {
+	t.Parallel()
+
+	f := func(ctx context.Context, s string, n int) (string, error) {
+		return fmt.Sprintf("%q %d", s, n), nil
+	}
+	byPosition := jsonrpc2.Call2(f)
+	byName := jsonrpc2.Call2(f, jsonrpc2.ParamNames("s", "n"))
+
+	RunParamsTests(t, resultString, []ParamsTest{
+		{"by-position", byPosition, `["a", 1]`, `"a" 1`, 0},
+		{"too-few", byPosition, `["a"]`, `"a" 0`, 0},
+		{"too-many", byPosition, `["a", 1, 2]`, "", jsonrpc2.InvalidParamsCode},
+		{"wrong-type", byPosition, `[1, "a"]`, "", jsonrpc2.InvalidParamsCode},
+		{"by-name-without-names", byPosition, `{"s": "a", "n": 1}`, "", jsonrpc2.InvalidParamsCode},
+		{"by-name", byName, `{"s": "a", "n": 1}`, `"a" 1`, 0},
+		{"by-name-too-few", byName, `{"n": 1}`, `"" 1`, 0},
+		{"by-name-and-by-position", byName, `["a", 1]`, `"a" 1`, 0},
+		{"scalar", byName, `"a"`, "", jsonrpc2.InvalidParamsCode},
+	})
+}
+
+func TestCall3(t *testing.T) {
+	t.Parallel()
+
+	f := func(ctx context.Context, s string, n int, b bool) (string, error) {
+		return fmt.Sprintf("%q %d %v", s, n, b), nil
+	}
+	byPosition := jsonrpc2.Call3(f)
+	byName := jsonrpc2.Call3(f, jsonrpc2.ParamNames("s", "n", "b"))
+
+	RunParamsTests(t, resultString, []ParamsTest{
+		{"by-position", byPosition, `["a", 1, true]`, `"a" 1 true`, 0},
+		{"too-few", byPosition, `["a", 1]`, `"a" 1 false`, 0},
+		{"too-many", byPosition, `["a", 1, true, 2]`, "", jsonrpc2.InvalidParamsCode},
+		{"by-name-without-names", byPosition, `{"s": "a", "n": 1, "b": true}`, "", jsonrpc2.InvalidParamsCode},
+		{"by-name", byName, `{"b": true, "n": 1, "s": "a"}`, `"a" 1 true`, 0},
+		{"by-name-too-few", byName, `{"b": true}`, `"" 0 true`, 0},
+		{"by-name-unknown", byName, `{"s": "a", "x": 1}`, `"a" 0 false`, 0},
+	})
+}
+
+func TestNotify2(t *testing.T) {
+	t.Parallel()
+
+	var got string
+	f := func(ctx context.Context, s string, n int) error {
+		got = fmt.Sprintf("%q %d", s, n)
+		return nil
+	}
+	byPosition := jsonrpc2.Notify2(f)
+	byName := jsonrpc2.Notify2(f, jsonrpc2.ParamNames("s", "n"))
+
+	RunParamsTests(t, func(any) string { return got }, []ParamsTest{
+		{"by-position", byPosition, `["a", 1]`, `"a" 1`, 0},
+		{"too-few", byPosition, `[]`, `"" 0`, 0},
+		{"too-many", byPosition, `["a", 1, 2]`, "", jsonrpc2.InvalidParamsCode},
+		{"by-name-without-names", byPosition, `{"s": "a"}`, "", jsonrpc2.InvalidParamsCode},
+		{"by-name", byName, `{"s": "b", "n": 2}`, `"b" 2`, 0},
+		{"by-name-too-few", byName, `{"s": "c"}`, `"c" 0`, 0},
+	})
+}
+
+func TestNotify3(t *testing.T) {
+	t.Parallel()
+
+	var got string
+	f := func(ctx context.Context, s string, n int, b bool) error {
+		got = fmt.Sprintf("%q %d %v", s, n, b)
+		return nil
+	}
+	byPosition := jsonrpc2.Notify3(f)
+	byName := jsonrpc2.Notify3(f, jsonrpc2.ParamNames("s", "n", "b"))
+
+	RunParamsTests(t, func(any) string { return got }, []ParamsTest{
+		{"by-position", byPosition, `["a", 1, true]`, `"a" 1 true`, 0},
+		{"too-few", byPosition, `["b"]`, `"b" 0 false`, 0},
+		{"too-many", byPosition, `["a", 1, true, null]`, "", jsonrpc2.InvalidParamsCode},
+		{"by-name-without-names", byPosition, `{"s": "a"}`, "", jsonrpc2.InvalidParamsCode},
+		{"by-name", byName, `{"s": "c", "n": 3, "b": true}`, `"c" 3 true`, 0},
+		{"by-name-too-few", byName, `{"n": 4}`, `"" 4 false`, 0},
+	})
+}
+
+func TestParamNames_count(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic for wrong number of names")
+		}
+	}()
+
+	jsonrpc2.Call2(func(ctx context.Context, a, b int) (int, error) {
+		return a + b, nil
+	}, jsonrpc2.ParamNames("a"))
+}
+
+type DeclaredParams struct {
+	Name    string `json:"name"`
+	Age     int    `json:"age"`
+	Ignored string `json:"-"`
+	private string
+}
+
+type PositionedParams struct {
+	Age   int    `json:"age" jsonrpc2:"position=1"`
+	Name  string `json:"name" jsonrpc2:"position=0"`
+	Admin bool   `json:"admin"`
+}
+
+type EmbeddedParams struct {
+	Range
+	Name string `json:"name"`
+}
+
+func TestCall_structParams(t *testing.T) {
+	t.Parallel()
+
+	declared := jsonrpc2.Call(func(ctx context.Context, p DeclaredParams) (string, error) {
+		return fmt.Sprintf("%s %d %q", p.Name, p.Age, p.Ignored), nil
+	})
+	positioned := jsonrpc2.Call(func(ctx context.Context, p PositionedParams) (string, error) {
+		return fmt.Sprintf("%s %d %v", p.Name, p.Age, p.Admin), nil
+	})
+	embedded := jsonrpc2.Call(func(ctx context.Context, p EmbeddedParams) (string, error) {
+		return fmt.Sprintf("%s %d-%d", p.Name, p.From, p.To), nil
+	})
+
+	RunParamsTests(t, resultString, []ParamsTest{
+		{"declared/by-position", declared, `["alice", 20]`, `alice 20 ""`, 0},
+		{"declared/too-few", declared, `["alice"]`, `alice 0 ""`, 0},
+		{"declared/too-many", declared, `["alice", 20, "x"]`, "", jsonrpc2.InvalidParamsCode},
+		{"declared/by-name", declared, `{"age": 20, "name": "alice"}`, `alice 20 ""`, 0},
+		{"positioned/by-position", positioned, `["bob", 30]`, `bob 30 false`, 0},
+		{"positioned/too-many", positioned, `["bob", 30, true]`, "", jsonrpc2.InvalidParamsCode},
+		{"positioned/by-name", positioned, `{"name": "bob", "age": 30, "admin": true}`, `bob 30 true`, 0},
+		{"embedded/by-position", embedded, `["carol"]`, `carol 0-0`, 0},
+		{"embedded/too-many", embedded, `["carol", 1]`, "", jsonrpc2.InvalidParamsCode},
+		{"embedded/by-name", embedded, `{"name": "carol", "from": 1, "to": 2}`, `carol 1-2`, 0},
+	})
+}
+
+type DuplicatedPosition struct {
+	A int `jsonrpc2:"position=0"`
+	B int `jsonrpc2:"position=0"`
+}
+
+type MissingPosition struct {
+	A int `jsonrpc2:"position=1"`
+}
+
+type InvalidPosition struct {
+	A int `jsonrpc2:"position=first"`
+}
+
+type EmbeddedPosition struct {
+	Range `jsonrpc2:"position=0"`
+}
+
+func TestCall_invalidPosition(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name string
+		F    func()
+	}{
+		{"duplicated", func() {
+			jsonrpc2.Call(func(ctx context.Context, p DuplicatedPosition) (int, error) { return 0, nil })
+		}},
+		{"missing", func() {
+			jsonrpc2.Call(func(ctx context.Context, p MissingPosition) (int, error) { return 0, nil })
+		}},
+		{"invalid", func() {
+			jsonrpc2.Notify(func(ctx context.Context, p InvalidPosition) error { return nil })
+		}},
+		{"embedded", func() {
+			jsonrpc2.Notify(func(ctx context.Context, p EmbeddedPosition) error { return nil })
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic")
+				}
+			}()
+			tt.F()
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package jsonrpc2
+
+import (
+	"context"
+	"net"
+)
+
+// PeerCred is the credentials of the process on the other side of a Unix domain socket.
+type PeerCred struct {
+	PID int32
+	UID uint32
+	GID uint32
+}
+
+// unixConn is a Unix domain socket connection with the credentials of the peer.
+// The credentials are captured when the connection is accepted.
+type unixConn struct {
+	*net.UnixConn
+
+	cred   PeerCred
+	credOK bool
+}
+
+func newUnixConn(conn *net.UnixConn) *unixConn {
+	cred, ok := peerCred(conn)
+	return &unixConn{UnixConn: conn, cred: cred, credOK: ok}
+}
+
+// PeerCredFromContext returns the credentials of the process that sent the request through a Unix domain socket.
+//
+// The credentials are available only on Linux, for connections accepted by `NewUnixListener`, or `NewListener` with a Unix domain socket listener.
+// It returns false otherwise.
+func PeerCredFromContext(ctx context.Context) (PeerCred, bool) {
+	conn, ok := ConnFromContext(ctx)
+	if !ok {
+		return PeerCred{}, false
+	}
+	uc, ok := conn.(*unixConn)
+	if !ok || !uc.credOK {
+		return PeerCred{}, false
+	}
+	return uc.cred, true
+}
@@ -0,0 +1,27 @@
+//go:build linux
+
+package jsonrpc2
+
+import (
+	"net"
+	"syscall"
+)
+
+// peerCred gets the credentials of the peer by SO_PEERCRED.
+func peerCred(conn *net.UnixConn) (PeerCred, bool) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return PeerCred{}, false
+	}
+
+	var ucred *syscall.Ucred
+	var serr error
+	err = raw.Control(func(fd uintptr) {
+		ucred, serr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	})
+	if err != nil || serr != nil {
+		return PeerCred{}, false
+	}
+
+	return PeerCred{PID: ucred.Pid, UID: ucred.Uid, GID: ucred.Gid}, true
+}
@@ -0,0 +1,61 @@
+package jsonrpc2_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/macrat/go-jsonrpc2"
+)
+
+func TestPeerCredFromContext(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "sock")
+
+	l, err := jsonrpc2.NewUnixListener(path)
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+
+	server := jsonrpc2.NewServer()
+	server.On("whoami", jsonrpc2.Call(func(ctx context.Context, _ any) (jsonrpc2.PeerCred, error) {
+		cred, ok := jsonrpc2.PeerCredFromContext(ctx)
+		if !ok {
+			return cred, jsonrpc2.ErrInternalError
+		}
+		return cred, nil
+	}))
+
+	go server.Serve(l)
+	defer server.Close()
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("failed to dial: %s", err)
+	}
+	defer conn.Close()
+
+	client := jsonrpc2.NewClient(conn)
+	defer client.Close()
+
+	var cred jsonrpc2.PeerCred
+	if err := client.Call(context.Background(), "whoami", nil, &cred); err != nil {
+		t.Fatalf("failed to call: %s", err)
+	}
+
+	expected := jsonrpc2.PeerCred{
+		PID: int32(os.Getpid()),
+		UID: uint32(os.Getuid()),
+		GID: uint32(os.Getgid()),
+	}
+	if cred != expected {
+		t.Errorf("unexpected credentials: expected %+v but got %+v", expected, cred)
+	}
+
+	if _, ok := jsonrpc2.PeerCredFromContext(context.Background()); ok {
+		t.Errorf("context without connection should return false")
+	}
+}
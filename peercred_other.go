@@ -0,0 +1,12 @@
+//go:build !linux
+
+package jsonrpc2
+
+import (
+	"net"
+)
+
+// peerCred is not supported on this platform.
+func peerCred(conn *net.UnixConn) (PeerCred, bool) {
+	return PeerCred{}, false
+}
@@ -0,0 +1,436 @@
+package jsonrpc2
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+var (
+	// ErrNotificationBufferFull is returned by `ReconnectingClient.Notify` if the client is disconnected and the notification buffer is full.
+	ErrNotificationBufferFull = errors.New("jsonrpc2: notification buffer is full")
+)
+
+// Dialer opens a new connection to the server.
+type Dialer func(ctx context.Context) (io.ReadWriteCloser, error)
+
+// NetDialer returns a Dialer that connects to the address on the named network using net.Dialer.
+func NetDialer(network, address string) Dialer {
+	var d net.Dialer
+	return func(ctx context.Context) (io.ReadWriteCloser, error) {
+		return d.DialContext(ctx, network, address)
+	}
+}
+
+// ConnectionState is a state of the connection of `ReconnectingClient`.
+type ConnectionState int
+
+const (
+	// StateConnecting means the client is connecting to the server for the first time.
+	StateConnecting ConnectionState = iota
+
+	// StateConnected means the client is connected to the server.
+	StateConnected
+
+	// StateReconnecting means the connection is lost and the client is trying to connect again.
+	StateReconnecting
+
+	// StateClosed means the client is closed.
+	StateClosed
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// ReplayPolicy specifies how `ReconnectingClient` handles in-flight calls when the connection is lost.
+type ReplayPolicy int
+
+const (
+	// FailInFlight makes in-flight calls fail with `*ConnectionError`.
+	// This is the default policy.
+	FailInFlight ReplayPolicy = iota
+
+	// ReplayInFlight sends in-flight calls again after reconnecting.
+	// Please use this policy only if the methods are idempotent, because the server may have processed them already.
+	ReplayInFlight
+)
+
+// WithReconnectBackoff specifies the delay between attempts to connect for `NewReconnectingClient`.
+//
+// The delay starts from `initial` and doubles on each failure up to `max`.
+// A random jitter of up to half the delay is subtracted to avoid reconnecting at the same time with other clients.
+// If this option is not specified, the delay is from 100ms to 30s.
+//
+// initial must be greater than 0, and max must be greater than or equal to initial.
+func WithReconnectBackoff(initial, max time.Duration) ClientOption {
+	if initial <= 0 {
+		panic("jsonrpc2: initial for jsonrpc2.WithReconnectBackoff must be greater than 0")
+	}
+	if max < initial {
+		panic("jsonrpc2: max for jsonrpc2.WithReconnectBackoff must be greater than or equal to initial")
+	}
+	return func(c *clientConfig) {
+		c.backoffInitial = initial
+		c.backoffMax = max
+	}
+}
+
+// WithReplayPolicy specifies how `ReconnectingClient` handles in-flight calls when the connection is lost.
+// If this option is not specified, `FailInFlight` is used.
+func WithReplayPolicy(p ReplayPolicy) ClientOption {
+	return func(c *clientConfig) {
+		c.replayPolicy = p
+	}
+}
+
+// WithNotificationBuffer specifies the number of notifications that `ReconnectingClient` keeps while disconnected.
+//
+// Buffered notifications are sent after reconnecting.
+// `Notify` returns `ErrNotificationBufferFull` if the buffer is full.
+// If this option is not specified, up to 100 notifications are buffered.
+//
+// n must not be negative. If n is 0, `Notify` fails while disconnected.
+func WithNotificationBuffer(n int) ClientOption {
+	if n < 0 {
+		panic("jsonrpc2: n for jsonrpc2.WithNotificationBuffer must not be negative")
+	}
+	return func(c *clientConfig) {
+		c.notificationBuffer = n
+	}
+}
+
+// WithStateHandler specifies the function that is called when the connection state of `ReconnectingClient` changes.
+//
+// The `err` is the reason of the change, such as `*ConnectionError` when the connection is lost. It can be nil.
+// The function is also called with the current state and the error each time an attempt to connect fails,
+// such as `StateReconnecting` and the error of the `Dialer`.
+// The function is called from a single goroutine in the order of changes,
+// so it should not block for long.
+func WithStateHandler(f func(state ConnectionState, err error)) ClientOption {
+	return func(c *clientConfig) {
+		c.stateHandler = f
+	}
+}
+
+// ReconnectingClient is a JSON-RPC 2.0 client that connects to the server again when the connection is lost.
+//
+// ReconnectingClient has the same API as `Client`.
+// Calls while disconnected wait until the client is connected again, or until the context is done.
+type ReconnectingClient struct {
+	caller
+
+	conf   clientConfig
+	dial   Dialer
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu       sync.Mutex
+	state    ConnectionState
+	client   *Client
+	ready    chan struct{}
+	buffered []*Request[any]
+}
+
+// NewReconnectingClient creates a new JSON-RPC 2.0 client that connects to the server by `dial`.
+//
+// This function does not wait for the connection.
+// It starts a goroutine to connect and reconnect to the server.
+// Please make sure to call `Close` to stop the goroutine when you are done.
+func NewReconnectingClient(dial Dialer, opts ...ClientOption) *ReconnectingClient {
+	if dial == nil {
+		panic("jsonrpc2: dial for jsonrpc2.NewReconnectingClient is nil")
+	}
+
+	conf := newClientConfig(opts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c := &ReconnectingClient{
+		conf:   conf,
+		dial:   dial,
+		cancel: cancel,
+		done:   make(chan struct{}),
+		state:  StateConnecting,
+		ready:  make(chan struct{}),
+	}
+	c.caller.init(conf, c.roundTrip, c.roundTripBatch)
+
+	go c.run(ctx)
+
+	return c
+}
+
+// State returns the current connection state.
+func (c *ReconnectingClient) State() ConnectionState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// setState changes the state and calls the state handler.
+// The handler is called even if the state does not change, when `err` is not nil.
+func (c *ReconnectingClient) setState(state ConnectionState, err error) {
+	c.mu.Lock()
+	changed := c.state != state
+	c.state = state
+	c.mu.Unlock()
+
+	if (changed || err != nil) && c.conf.stateHandler != nil {
+		c.conf.stateHandler(state, err)
+	}
+}
+
+// backoff returns the delay before the next attempt to connect.
+func (c *ReconnectingClient) backoff(attempt int) time.Duration {
+	d := c.conf.backoffInitial
+	for i := 0; i < attempt && d < c.conf.backoffMax; i++ {
+		d *= 2
+	}
+	d = min(d, c.conf.backoffMax)
+
+	if d > 1 {
+		d -= rand.N(d / 2)
+	}
+	return d
+}
+
+// connect dials to the server until it succeeds or the context is done.
+// Errors of attempts are reported to the state handler with the current state.
+func (c *ReconnectingClient) connect(ctx context.Context) (*Client, error) {
+	for attempt := 0; ; attempt++ {
+		rwc, err := c.dial(ctx)
+		if err == nil {
+			cctx, cancel := context.WithCancel(context.Background())
+			client := newClient(c.conf, newFrameReader(c.conf.framing, rwc), newFrameWriter(c.conf.framing, rwc), func() {
+				cancel()
+				rwc.Close()
+			})
+			go client.run(cctx)
+			return client, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		c.setState(c.State(), err)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(c.backoff(attempt)):
+		}
+	}
+}
+
+func (c *ReconnectingClient) run(ctx context.Context) {
+	defer close(c.done)
+	defer c.setState(StateClosed, ErrClientClosed)
+
+	for {
+		client, err := c.connect(ctx)
+		if err != nil {
+			return
+		}
+
+		if err := c.replay(ctx, client); err != nil {
+			client.Close()
+			if ctx.Err() != nil {
+				return
+			}
+			c.setState(StateReconnecting, err)
+			continue
+		}
+
+		c.setState(StateConnected, nil)
+
+		select {
+		case <-ctx.Done():
+			client.Close()
+			return
+		case <-client.Done():
+		}
+		err = client.Err()
+		client.Close()
+		c.detach(client)
+
+		c.setState(StateReconnecting, err)
+	}
+}
+
+// replay sends the buffered notifications through `client`, and then makes `client` the current client.
+//
+// The notifications are sent without holding the lock, so `Notify` can buffer new notifications meanwhile.
+// They are sent in the next round to keep the order.
+// If sending fails, the unsent notifications are buffered again to send after the next connection.
+func (c *ReconnectingClient) replay(ctx context.Context, client *Client) error {
+	// Writes to a stalled peer do not return by the context, so close the connection to stop them.
+	stop := context.AfterFunc(ctx, func() { client.Close() })
+	defer stop()
+
+	for {
+		c.mu.Lock()
+		buffered := c.buffered
+		c.buffered = nil
+		if len(buffered) == 0 {
+			c.client = client
+			close(c.ready)
+			c.mu.Unlock()
+			return nil
+		}
+		c.mu.Unlock()
+
+		for i, req := range buffered {
+			if _, err := client.roundTrip(ctx, req); err != nil {
+				c.mu.Lock()
+				c.buffered = slices.Concat(buffered[i:], c.buffered)
+				c.mu.Unlock()
+				return err
+			}
+		}
+	}
+}
+
+// detach forgets `client` if it is still the current client, so that callers wait for the next connection.
+// It does nothing if `client` is already detached.
+func (c *ReconnectingClient) detach(client *Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.client == client {
+		c.client = nil
+		c.ready = make(chan struct{})
+	}
+}
+
+// current returns the connected client.
+// If the client is disconnected, it waits for reconnecting unless `notify` is not nil.
+// If `notify` is not nil, it is buffered and current returns nil client.
+func (c *ReconnectingClient) current(ctx context.Context, notify *Request[any]) (*Client, error) {
+	c.mu.Lock()
+	client, ready := c.client, c.ready
+
+	if client == nil && notify != nil {
+		defer c.mu.Unlock()
+
+		select {
+		case <-c.done:
+			return nil, ErrClientClosed
+		default:
+		}
+
+		if len(c.buffered) >= c.conf.notificationBuffer {
+			return nil, ErrNotificationBufferFull
+		}
+		c.buffered = append(c.buffered, notify)
+		return nil, nil
+	}
+	c.mu.Unlock()
+
+	if client != nil {
+		return client, nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.done:
+		return nil, ErrClientClosed
+	case <-ready:
+		return c.current(ctx, notify)
+	}
+}
+
+// retry reports whether the failed call should be sent again.
+func (c *ReconnectingClient) retry(ctx context.Context, err error) bool {
+	var connErr *ConnectionError
+	return c.conf.replayPolicy == ReplayInFlight && errors.As(err, &connErr) && ctx.Err() == nil
+}
+
+// roundTrip is the Invoker that sends a request through the current connection.
+func (c *ReconnectingClient) roundTrip(ctx context.Context, req *Request[any]) (*Response[json.RawMessage], error) {
+	var notify *Request[any]
+	if req.ID == nil {
+		notify = req
+	}
+
+	for {
+		client, err := c.current(ctx, notify)
+		if err != nil || client == nil {
+			return nil, err
+		}
+
+		res, err := client.roundTrip(ctx, req)
+		if !c.retry(ctx, err) {
+			return res, err
+		}
+
+		// The connection is lost, but `run` may not have noticed it yet.
+		// Detach the dead client so that the next round waits for reconnecting instead of failing again immediately.
+		c.detach(client)
+	}
+}
+
+// roundTripBatch is the BatchInvoker that sends requests through the current connection.
+func (c *ReconnectingClient) roundTripBatch(ctx context.Context, reqs []*Request[any]) ([]*Response[json.RawMessage], error) {
+	for {
+		client, err := c.current(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := client.roundTripBatch(ctx, reqs)
+		if !c.retry(ctx, err) {
+			return res, err
+		}
+
+		// Wait for reconnecting, the same as `roundTrip`.
+		c.detach(client)
+	}
+}
+
+// Close stops the client and closes the current connection.
+//
+// Pending calls fail with `ErrClientClosed` and buffered notifications are discarded.
+func (c *ReconnectingClient) Close() error {
+	c.cancel()
+	<-c.done
+	return nil
+}
+
+// Call calls a method on the server.
+//
+// The response from the server is unmarshaled into the `result` parameter.
+// If you do not need the response, use `Notify` instead.
+func (c *ReconnectingClient) Call(ctx context.Context, name string, params any, result any) error {
+	return c.caller.call(ctx, name, params, result)
+}
+
+// Notify sends a notification to the server.
+//
+// If the client is disconnected, the notification is buffered and sent after reconnecting.
+func (c *ReconnectingClient) Notify(ctx context.Context, name string, params any) error {
+	return c.caller.notify(ctx, name, params)
+}
+
+// Batch sends multiple requests to the server at once.
+func (c *ReconnectingClient) Batch(ctx context.Context, reqs []BatchRequest) ([]*BatchResponse, error) {
+	return c.caller.batch(ctx, reqs)
+}
@@ -0,0 +1,516 @@
+package jsonrpc2_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/macrat/go-jsonrpc2"
+)
+
+// ReconnectTestServer is a server for testing ReconnectingClient.
+// It can drop connections and refuse new connections.
+type ReconnectTestServer struct {
+	t      *testing.T
+	server *jsonrpc2.Server
+
+	mu      sync.Mutex
+	conns   []io.Closer
+	refuse  bool
+	dialed  chan struct{}
+	count   atomic.Int64
+	entered chan struct{}
+}
+
+func NewReconnectTestServer(t *testing.T) *ReconnectTestServer {
+	s := &ReconnectTestServer{
+		t:       t,
+		server:  jsonrpc2.NewServer(),
+		dialed:  make(chan struct{}, 10),
+		entered: make(chan struct{}, 10),
+	}
+
+	s.server.On("add", jsonrpc2.Call(func(ctx context.Context, xs []int) (int, error) {
+		return xs[0] + xs[1], nil
+	}))
+
+	s.server.On("count", jsonrpc2.Notify(func(ctx context.Context, n int64) error {
+		s.count.Add(n)
+		return nil
+	}))
+
+	// "block" blocks until the connection is lost, only for the first call.
+	var blocked atomic.Bool
+	s.server.On("block", jsonrpc2.Call(func(ctx context.Context, _ any) (string, error) {
+		if !blocked.Swap(true) {
+			s.entered <- struct{}{}
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return "ok", nil
+	}))
+
+	return s
+}
+
+func (s *ReconnectTestServer) Dial(ctx context.Context) (io.ReadWriteCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.refuse {
+		return nil, errors.New("connection refused")
+	}
+
+	cli, srv := BiDirectionalPipe(s.t)
+	s.conns = append(s.conns, srv)
+	go s.server.ServeForOne(srv)
+
+	s.dialed <- struct{}{}
+
+	return cli, nil
+}
+
+// Drop closes all connections, and refuses new connections if `refuse` is true.
+func (s *ReconnectTestServer) Drop(refuse bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.conns {
+		c.Close()
+	}
+	s.conns = nil
+	s.refuse = refuse
+}
+
+func (s *ReconnectTestServer) Accept() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refuse = false
+}
+
+func (s *ReconnectTestServer) WaitDial(t *testing.T) {
+	t.Helper()
+
+	select {
+	case <-s.dialed:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("client did not connect")
+	}
+}
+
+type StateRecorder struct {
+	mu     sync.Mutex
+	states []jsonrpc2.ConnectionState
+	ch     chan jsonrpc2.ConnectionState
+}
+
+func NewStateRecorder() *StateRecorder {
+	return &StateRecorder{ch: make(chan jsonrpc2.ConnectionState, 100)}
+}
+
+func (r *StateRecorder) Handle(state jsonrpc2.ConnectionState, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	changed := len(r.states) == 0 || r.states[len(r.states)-1] != state
+	r.states = append(r.states, state)
+	if changed {
+		r.ch <- state
+	}
+}
+
+func (r *StateRecorder) Wait(t *testing.T, want jsonrpc2.ConnectionState) {
+	t.Helper()
+
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case s := <-r.ch:
+			if s == want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("state did not become %s", want)
+		}
+	}
+}
+
+// States returns the recorded states.
+// Consecutive same states, that are reported for each failed attempt to connect, are merged.
+func (r *StateRecorder) States() []jsonrpc2.ConnectionState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Compact(slices.Clone(r.states))
+}
+
+func TestReconnectingClient(t *testing.T) {
+	t.Parallel()
+
+	server := NewReconnectTestServer(t)
+	states := NewStateRecorder()
+
+	client := jsonrpc2.NewReconnectingClient(
+		server.Dial,
+		jsonrpc2.WithReconnectBackoff(time.Millisecond, 10*time.Millisecond),
+		jsonrpc2.WithNotificationBuffer(2),
+		jsonrpc2.WithStateHandler(states.Handle),
+	)
+
+	ctx := context.Background()
+
+	var sum int
+	if err := client.Call(ctx, "add", []int{1, 2}, &sum); err != nil {
+		t.Fatalf("failed to call: %s", err)
+	} else if sum != 3 {
+		t.Errorf("unexpected result: %d", sum)
+	}
+	server.WaitDial(t)
+
+	// In-flight calls fail by default.
+	errCh := make(chan error)
+	go func() {
+		var s string
+		errCh <- client.Call(ctx, "block", nil, &s)
+	}()
+	<-server.entered
+	server.Drop(true)
+
+	var connErr *jsonrpc2.ConnectionError
+	if err := <-errCh; !errors.As(err, &connErr) {
+		t.Errorf("unexpected error of in-flight call: %v", err)
+	}
+	states.Wait(t, jsonrpc2.StateReconnecting)
+
+	// Notifications are buffered while disconnected.
+	for i := 0; i < 2; i++ {
+		if err := client.Notify(ctx, "count", 1); err != nil {
+			t.Fatalf("failed to buffer notification: %s", err)
+		}
+	}
+	if err := client.Notify(ctx, "count", 1); !errors.Is(err, jsonrpc2.ErrNotificationBufferFull) {
+		t.Errorf("unexpected error of overflowed notification: %v", err)
+	}
+
+	// Calls wait for reconnecting.
+	go func() {
+		errCh <- client.Call(ctx, "add", []int{3, 4}, &sum)
+	}()
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	var ignored int
+	if err := client.Call(timeout, "add", []int{1, 1}, &ignored); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected error of timed out call: %v", err)
+	}
+	cancel()
+
+	server.Accept()
+	server.WaitDial(t)
+
+	if err := <-errCh; err != nil {
+		t.Errorf("failed to call after reconnecting: %s", err)
+	} else if sum != 7 {
+		t.Errorf("unexpected result after reconnecting: %d", sum)
+	}
+
+	for start := time.Now(); server.count.Load() < 2; {
+		if time.Since(start) > 10*time.Second {
+			t.Fatalf("buffered notifications did not arrive")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := server.count.Load(); n != 2 {
+		t.Errorf("unexpected number of notifications: %d", n)
+	}
+
+	client.Close()
+
+	if err := client.Call(ctx, "add", []int{1, 2}, &sum); !errors.Is(err, jsonrpc2.ErrClientClosed) {
+		t.Errorf("unexpected error after close: %v", err)
+	}
+
+	expected := []jsonrpc2.ConnectionState{
+		jsonrpc2.StateConnected,
+		jsonrpc2.StateReconnecting,
+		jsonrpc2.StateConnected,
+		jsonrpc2.StateClosed,
+	}
+	if diff := cmp.Diff(expected, states.States()); diff != "" {
+		t.Errorf("unexpected states:\n%s", diff)
+	}
+}
+
+func TestReconnectingClient_replay(t *testing.T) {
+	t.Parallel()
+
+	server := NewReconnectTestServer(t)
+
+	client := jsonrpc2.NewReconnectingClient(
+		server.Dial,
+		jsonrpc2.WithReconnectBackoff(time.Millisecond, 10*time.Millisecond),
+		jsonrpc2.WithReplayPolicy(jsonrpc2.ReplayInFlight),
+	)
+	defer client.Close()
+
+	errCh := make(chan error)
+	var s string
+	go func() {
+		errCh <- client.Call(context.Background(), "block", nil, &s)
+	}()
+
+	<-server.entered
+	server.Drop(false)
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatalf("failed to replay: %s", err)
+		} else if s != "ok" {
+			t.Errorf("unexpected result: %q", s)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("call did not finish")
+	}
+}
+
+func TestReconnectingClient_replayWhileRefused(t *testing.T) {
+	t.Parallel()
+
+	server := NewReconnectTestServer(t)
+	states := NewStateRecorder()
+
+	client := jsonrpc2.NewReconnectingClient(
+		server.Dial,
+		jsonrpc2.WithReconnectBackoff(time.Millisecond, 10*time.Millisecond),
+		jsonrpc2.WithReplayPolicy(jsonrpc2.ReplayInFlight),
+		jsonrpc2.WithStateHandler(states.Handle),
+	)
+	defer client.Close()
+
+	errCh := make(chan error)
+	var s string
+	go func() {
+		errCh <- client.Call(context.Background(), "block", nil, &s)
+	}()
+
+	<-server.entered
+	server.Drop(true)
+	states.Wait(t, jsonrpc2.StateReconnecting)
+
+	// The in-flight call waits for reconnecting instead of retrying the dead connection.
+	select {
+	case err := <-errCh:
+		t.Fatalf("the call finished while disconnected: %v", err)
+	default:
+	}
+
+	server.Accept()
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatalf("failed to replay: %s", err)
+		} else if s != "ok" {
+			t.Errorf("unexpected result: %q", s)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("call did not finish")
+	}
+}
+
+func TestReconnectingClient_dialError(t *testing.T) {
+	t.Parallel()
+
+	server := NewReconnectTestServer(t)
+	server.Drop(true)
+
+	type report struct {
+		State jsonrpc2.ConnectionState
+		Err   error
+	}
+	reports := make(chan report, 100)
+
+	client := jsonrpc2.NewReconnectingClient(
+		server.Dial,
+		jsonrpc2.WithReconnectBackoff(time.Millisecond, 10*time.Millisecond),
+		jsonrpc2.WithStateHandler(func(state jsonrpc2.ConnectionState, err error) {
+			select {
+			case reports <- report{state, err}:
+			default:
+			}
+		}),
+	)
+	defer client.Close()
+
+	expect := func(state jsonrpc2.ConnectionState, match func(error) bool) {
+		t.Helper()
+
+		timeout := time.After(10 * time.Second)
+		for {
+			select {
+			case r := <-reports:
+				if r.State == state && match(r.Err) {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("expected report for %s is not received", state)
+			}
+		}
+	}
+	refused := func(err error) bool {
+		return err != nil && err.Error() == "connection refused"
+	}
+
+	// Errors of the Dialer are reported with the current state.
+	expect(jsonrpc2.StateConnecting, refused)
+
+	server.Accept()
+	expect(jsonrpc2.StateConnected, func(err error) bool { return err == nil })
+
+	server.Drop(true)
+	expect(jsonrpc2.StateReconnecting, func(err error) bool {
+		var connErr *jsonrpc2.ConnectionError
+		return errors.As(err, &connErr)
+	})
+	expect(jsonrpc2.StateReconnecting, refused)
+}
+
+// StallConn is a connection that blocks writes until it is closed, like a stalled peer.
+type StallConn struct {
+	writing   chan struct{}
+	writeOnce sync.Once
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func NewStallConn() *StallConn {
+	return &StallConn{writing: make(chan struct{}), closed: make(chan struct{})}
+}
+
+func (c *StallConn) Read(p []byte) (int, error) {
+	<-c.closed
+	return 0, io.EOF
+}
+
+func (c *StallConn) Write(p []byte) (int, error) {
+	c.writeOnce.Do(func() { close(c.writing) })
+	<-c.closed
+	return 0, io.ErrClosedPipe
+}
+
+func (c *StallConn) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return nil
+}
+
+func TestReconnectingClient_replayNotifications(t *testing.T) {
+	t.Parallel()
+
+	server := NewReconnectTestServer(t)
+	stall := NewStallConn()
+
+	// The first connection stalls, and the second one works.
+	gate := make(chan struct{})
+	var attempts atomic.Int32
+	dial := func(ctx context.Context) (io.ReadWriteCloser, error) {
+		select {
+		case <-gate:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if attempts.Add(1) == 1 {
+			return stall, nil
+		}
+		return server.Dial(ctx)
+	}
+
+	client := jsonrpc2.NewReconnectingClient(dial, jsonrpc2.WithReconnectBackoff(time.Millisecond, 10*time.Millisecond))
+	defer client.Close()
+
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := client.Notify(ctx, "count", 1); err != nil {
+			t.Fatalf("failed to buffer notification: %s", err)
+		}
+	}
+	close(gate)
+
+	select {
+	case <-stall.writing:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("buffered notifications are not sent")
+	}
+
+	// The client is not blocked while sending buffered notifications to the stalled peer.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if s := client.State(); s != jsonrpc2.StateConnecting {
+			t.Errorf("unexpected state: %s", s)
+		}
+		if err := client.Notify(ctx, "count", 1); err != nil {
+			t.Errorf("failed to buffer notification: %s", err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("the client is blocked while sending buffered notifications")
+	}
+
+	// Notifications that failed to send are sent after reconnecting.
+	stall.Close()
+	server.WaitDial(t)
+
+	var sum int
+	if err := client.Call(ctx, "add", []int{1, 2}, &sum); err != nil {
+		t.Fatalf("failed to call: %s", err)
+	}
+
+	for start := time.Now(); server.count.Load() < 4; {
+		if time.Since(start) > 10*time.Second {
+			t.Fatalf("buffered notifications did not arrive: %d", server.count.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWithReconnectBackoff_invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name         string
+		Initial, Max time.Duration
+	}{
+		{"zero", 0, time.Second},
+		{"negative", -time.Second, time.Second},
+		{"max-less-than-initial", time.Second, time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic")
+				}
+			}()
+			jsonrpc2.WithReconnectBackoff(tt.Initial, tt.Max)
+		})
+	}
+}
+
+func TestWithNotificationBuffer_invalid(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic")
+		}
+	}()
+	jsonrpc2.WithNotificationBuffer(-1)
+}
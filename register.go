@@ -0,0 +1,202 @@
+package jsonrpc2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrUnsupportedSignature = errors.New("jsonrpc2: unsupported method signature")
+)
+
+// NamingStrategy converts a Go method name into a JSON-RPC method name for `Server.Register`.
+type NamingStrategy func(name string) string
+
+// CamelCase is a NamingStrategy that converts "GetUserID" into "getUserID".
+func CamelCase(name string) string {
+	rs := []rune(name)
+	for i := range rs {
+		// Keep the last upper letter of an acronym, such as "S" in "HTTPServer".
+		if i > 0 && i+1 < len(rs) && unicode.IsUpper(rs[i]) && unicode.IsLower(rs[i+1]) {
+			break
+		}
+		if !unicode.IsUpper(rs[i]) {
+			break
+		}
+		rs[i] = unicode.ToLower(rs[i])
+	}
+	return string(rs)
+}
+
+// SnakeCase is a NamingStrategy that converts "GetUserID" into "get_user_id".
+func SnakeCase(name string) string {
+	rs := []rune(name)
+
+	var b strings.Builder
+	for i, r := range rs {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := rs[i-1]
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (i+1 < len(rs) && unicode.IsLower(rs[i+1])) {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// WithNamingStrategy specifies how `Server.Register` names methods.
+// If this option is not specified, the Go method names are used as-is.
+func WithNamingStrategy(f NamingStrategy) ServerOption {
+	return func(s *Server) {
+		s.naming = f
+	}
+}
+
+// Register registers all exported methods of `svc` as handlers.
+//
+// The methods must have either of the following signatures, like the functions for `Call` and `Notify`.
+//
+//	func (ctx context.Context, params I) (result O, err error)
+//	func (ctx context.Context, params I) error
+//
+// Each method is registered as "prefix.MethodName", or just "MethodName" if the prefix is empty.
+// The method name is converted by the strategy specified by `WithNamingStrategy`.
+// The `mws` parameter is middlewares for all methods, the same as `Server.On`.
+//
+// Exported methods that do not take context.Context as the first parameter, such as `String` or `Close`, are not handlers and skipped.
+// If a method takes context.Context but has another signature, Register returns an error wrapping `ErrUnsupportedSignature`, and registers nothing.
+// It also returns an error and registers nothing if params types have invalid `jsonrpc2` tags,
+// or if multiple methods have the same name after the naming strategy, such as "GetID" and "GetId" with `SnakeCase`.
+func (s *Server) Register(prefix string, svc any, mws ...Middleware) error {
+	if svc == nil {
+		return errors.New("jsonrpc2: service for Server.Register is nil")
+	}
+
+	v := reflect.ValueOf(svc)
+	t := v.Type()
+
+	handlers := make(map[string]Handler, t.NumMethod())
+	goNames := make(map[string]string, t.NumMethod())
+	var errs []error
+
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+
+		if ft := v.Method(i).Type(); ft.NumIn() == 0 || ft.In(0) != contextType {
+			continue
+		}
+
+		h, err := newReflectHandler(v.Method(i))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s.%s: %w", ErrUnsupportedSignature, t, m.Name, err))
+			continue
+		}
+		if err := checkParamsType(h.params); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		name := m.Name
+		if s.naming != nil {
+			name = s.naming(name)
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		if other, ok := goNames[name]; ok {
+			errs = append(errs, fmt.Errorf("jsonrpc2: %s.%s and %s.%s have the same name %q", t, other, t, m.Name, name))
+			continue
+		}
+		handlers[name] = h
+		goNames[name] = m.Name
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if len(handlers) == 0 {
+		return fmt.Errorf("jsonrpc2: %s has no handler methods", t)
+	}
+
+	for name, h := range handlers {
+		s.On(name, h, mws...)
+	}
+
+	return nil
+}
+
+var (
+	contextType = reflect.TypeFor[context.Context]()
+	errorType   = reflect.TypeFor[error]()
+)
+
+// reflectHandler is a handler that calls a function through reflection.
+type reflectHandler struct {
+	fn        reflect.Value
+	params    reflect.Type
+	hasResult bool
+}
+
+func newReflectHandler(fn reflect.Value) (reflectHandler, error) {
+	t := fn.Type()
+
+	if t.NumIn() != 2 {
+		return reflectHandler{}, fmt.Errorf("must have 2 parameters but has %d", t.NumIn())
+	}
+	if t.In(0) != contextType {
+		return reflectHandler{}, fmt.Errorf("the first parameter must be context.Context but got %s", t.In(0))
+	}
+	if t.NumOut() != 1 && t.NumOut() != 2 {
+		return reflectHandler{}, fmt.Errorf("must have 1 or 2 results but has %d", t.NumOut())
+	}
+	if t.Out(t.NumOut()-1) != errorType {
+		return reflectHandler{}, fmt.Errorf("the last result must be error but got %s", t.Out(t.NumOut()-1))
+	}
+
+	return reflectHandler{
+		fn:        fn,
+		params:    t.In(1),
+		hasResult: t.NumOut() == 2,
+	}, nil
+}
+
+func (h reflectHandler) signature() handlerSignature {
+	sig := handlerSignature{
+		params: []reflect.Type{h.params},
+	}
+	if h.hasResult {
+		sig.result = h.fn.Type().Out(0)
+	}
+	return sig
+}
+
+func (h reflectHandler) ServeJSONRPC2(ctx context.Context, r RawRequest) (any, error) {
+	params := reflect.New(h.params)
+
+	if err := unmarshalParams(r.Params, params.Interface()); err != nil {
+		return nil, ErrInvalidParams
+	}
+	if isStrict(ctx, handlerConfig{}) {
+		if err := checkStrictParams(r.Params, h.params); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateParams(paramsPath, params.Interface()); err != nil {
+		return nil, err
+	}
+
+	out := h.fn.Call([]reflect.Value{reflect.ValueOf(ctx), params.Elem()})
+
+	if err := out[len(out)-1]; !err.IsNil() {
+		return nil, err.Interface().(error)
+	}
+	if h.hasResult {
+		return out[0].Interface(), nil
+	}
+	return nil, nil
+}
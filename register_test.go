@@ -0,0 +1,215 @@
+package jsonrpc2_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/macrat/go-jsonrpc2"
+)
+
+type MathService struct {
+	logs []string
+}
+
+func (s *MathService) Add(ctx context.Context, xs []int) (int, error) {
+	sum := 0
+	for _, x := range xs {
+		sum += x
+	}
+	return sum, nil
+}
+
+func (s *MathService) Div(ctx context.Context, xs [2]int) (int, error) {
+	if xs[1] == 0 {
+		return 0, jsonrpc2.Error{Code: 1, Message: "division by zero"}
+	}
+	return xs[0] / xs[1], nil
+}
+
+func (s *MathService) WriteLog(ctx context.Context, msg string) error {
+	s.logs = append(s.logs, msg)
+	return nil
+}
+
+// String is not a handler, so Register skips it.
+func (s *MathService) String() string {
+	return "MathService"
+}
+
+// Close is not a handler, so Register skips it.
+func (s *MathService) Close() error {
+	return nil
+}
+
+type BrokenService struct{}
+
+func (BrokenService) NoParams(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (BrokenService) NoError(ctx context.Context, x int) int {
+	return x
+}
+
+func (BrokenService) Good(ctx context.Context, x int) (int, error) {
+	return x, nil
+}
+
+func TestServer_Register(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name   string
+		Naming jsonrpc2.NamingStrategy
+		Prefix string
+		Add    string
+		Div    string
+		Log    string
+	}{
+		{"as-is", nil, "math", "math.Add", "math.Div", "math.WriteLog"},
+		{"camel", jsonrpc2.CamelCase, "math", "math.add", "math.div", "math.writeLog"},
+		{"snake", jsonrpc2.SnakeCase, "", "add", "div", "write_log"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &MathService{}
+			server := jsonrpc2.NewServer(jsonrpc2.WithNamingStrategy(tt.Naming))
+			if err := server.Register(tt.Prefix, svc); err != nil {
+				t.Fatalf("failed to register: %s", err)
+			}
+
+			ts := httptest.NewServer(server)
+			defer ts.Close()
+
+			client := jsonrpc2.NewHTTPClient(ts.URL, nil)
+			ctx := context.Background()
+
+			var n int
+			if err := client.Call(ctx, tt.Add, []int{1, 2, 3}, &n); err != nil {
+				t.Errorf("failed to call %s: %s", tt.Add, err)
+			} else if n != 6 {
+				t.Errorf("unexpected result of %s: %d", tt.Add, n)
+			}
+
+			if err := client.Call(ctx, tt.Div, []int{1, 0}, &n); err == nil || err.Error() != "division by zero (1)" {
+				t.Errorf("unexpected error of %s: %v", tt.Div, err)
+			}
+
+			if err := client.Call(ctx, tt.Div, "hello", &n); err == nil || err.Error() != "Invalid params (-32602)" {
+				t.Errorf("unexpected error of %s with invalid params: %v", tt.Div, err)
+			}
+
+			if err := client.Notify(ctx, tt.Log, "hello"); err != nil {
+				t.Errorf("failed to notify %s: %s", tt.Log, err)
+			} else if len(svc.logs) != 1 || svc.logs[0] != "hello" {
+				t.Errorf("unexpected logs: %v", svc.logs)
+			}
+		})
+	}
+}
+
+func TestServer_Register_unsupported(t *testing.T) {
+	t.Parallel()
+
+	server := jsonrpc2.NewServer()
+
+	err := server.Register("broken", BrokenService{})
+	if !errors.Is(err, jsonrpc2.ErrUnsupportedSignature) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, name := range []string{"NoParams", "NoError"} {
+		if !strings.Contains(err.Error(), "BrokenService."+name) {
+			t.Errorf("error does not mention %s: %s", name, err)
+		}
+	}
+	if strings.Contains(err.Error(), "Good") {
+		t.Errorf("error mentions a valid method: %s", err)
+	}
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	var n int
+	if err := jsonrpc2.NewHTTPClient(ts.URL, nil).Call(context.Background(), "broken.Good", 1, &n); err == nil || err.Error() != "Method not found (-32601)" {
+		t.Errorf("valid method of broken service should not be registered: %v", err)
+	}
+}
+
+type CollidingService struct{}
+
+func (CollidingService) GetID(ctx context.Context, _ any) (int, error) {
+	return 1, nil
+}
+
+func (CollidingService) GetId(ctx context.Context, _ any) (int, error) {
+	return 2, nil
+}
+
+type NoHandlerService struct{}
+
+func (NoHandlerService) String() string {
+	return "NoHandlerService"
+}
+
+func TestServer_Register_invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name    string
+		Naming  jsonrpc2.NamingStrategy
+		Service any
+		Error   string
+	}{
+		{"collision", jsonrpc2.SnakeCase, CollidingService{}, `CollidingService.GetID and jsonrpc2_test.CollidingService.GetId have the same name "svc.get_id"`},
+		{"no-handler", nil, NoHandlerService{}, "has no handler methods"},
+		{"nil", nil, nil, "is nil"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			server := jsonrpc2.NewServer(jsonrpc2.WithNamingStrategy(tt.Naming))
+			err := server.Register("svc", tt.Service)
+			if err == nil || !strings.Contains(err.Error(), tt.Error) {
+				t.Errorf("expected an error contains %q but got %v", tt.Error, err)
+			}
+		})
+	}
+
+	// Without the naming strategy, the names do not collide.
+	if err := jsonrpc2.NewServer().Register("svc", CollidingService{}); err != nil {
+		t.Errorf("failed to register: %s", err)
+	}
+}
+
+func TestNamingStrategy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Input string
+		Camel string
+		Snake string
+	}{
+		{"Add", "add", "add"},
+		{"GetUserID", "getUserID", "get_user_id"},
+		{"HTTPServer", "httpServer", "http_server"},
+		{"ID", "id", "id"},
+		{"V2Status", "v2Status", "v2_status"},
+	}
+
+	for _, tt := range tests {
+		if got := jsonrpc2.CamelCase(tt.Input); got != tt.Camel {
+			t.Errorf("CamelCase(%q): want=%q got=%q", tt.Input, tt.Camel, got)
+		}
+		if got := jsonrpc2.SnakeCase(tt.Input); got != tt.Snake {
+			t.Errorf("SnakeCase(%q): want=%q got=%q", tt.Input, tt.Snake, got)
+		}
+	}
+}
@@ -4,9 +4,10 @@ import (
 	"context"
 	"errors"
 	"io"
+	"reflect"
 	"sort"
-
-	"github.com/goccy/go-json"
+	"sync"
+	"sync/atomic"
 )
 
 // Handler is a base interface for JSON-RPC 2.0 handlers.
@@ -18,60 +19,166 @@ type Handler interface {
 }
 
 // Call creates a new JSON-RPC 2.0 handler for a method that returns a result.
-func Call[I, O any](f func(context.Context, I) (O, error)) Handler {
-	return callHandler[I, O](f)
+func Call[I, O any](f func(context.Context, I) (O, error), opts ...HandlerOption) Handler {
+	mustCheckParamsType(reflect.TypeFor[I]())
+	return callHandler[I, O]{f, newHandlerConfig(opts)}
 }
 
-type callHandler[I, O any] func(context.Context, I) (O, error)
+type callHandler[I, O any] struct {
+	f    func(context.Context, I) (O, error)
+	conf handlerConfig
+}
 
-func (f callHandler[I, O]) ServeJSONRPC2(ctx context.Context, r RawRequest) (any, error) {
+func (h callHandler[I, O]) ServeJSONRPC2(ctx context.Context, r RawRequest) (any, error) {
 	var params I
 
-	if err := json.Unmarshal(r.Params, &params); err != nil {
+	if err := unmarshalParams(r.Params, &params); err != nil {
 		return nil, ErrInvalidParams
 	}
+	if isStrict(ctx, h.conf) {
+		if err := checkStrictParams(r.Params, reflect.TypeFor[I]()); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateParams(paramsPath, &params); err != nil {
+		return nil, err
+	}
 
-	return f(ctx, params)
+	return h.f(ctx, params)
+}
+
+func (h callHandler[I, O]) signature() handlerSignature {
+	return handlerSignature{
+		params: []reflect.Type{reflect.TypeFor[I]()},
+		result: reflect.TypeFor[O](),
+		conf:   h.conf,
+	}
 }
 
 // Notify creates a new JSON-RPC 2.0 handler for a method that does not return a result.
-func Notify[I any](f func(context.Context, I) error) Handler {
-	return notifyHandler[I](f)
+func Notify[I any](f func(context.Context, I) error, opts ...HandlerOption) Handler {
+	mustCheckParamsType(reflect.TypeFor[I]())
+	return notifyHandler[I]{f, newHandlerConfig(opts)}
 }
 
-type notifyHandler[I any] func(context.Context, I) error
+type notifyHandler[I any] struct {
+	f    func(context.Context, I) error
+	conf handlerConfig
+}
 
-func (f notifyHandler[I]) ServeJSONRPC2(ctx context.Context, r RawRequest) (any, error) {
+func (h notifyHandler[I]) ServeJSONRPC2(ctx context.Context, r RawRequest) (any, error) {
 	var params I
 
-	if err := json.Unmarshal(r.Params, &params); err != nil {
+	if err := unmarshalParams(r.Params, &params); err != nil {
 		return nil, ErrInvalidParams
 	}
+	if isStrict(ctx, h.conf) {
+		if err := checkStrictParams(r.Params, reflect.TypeFor[I]()); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateParams(paramsPath, &params); err != nil {
+		return nil, err
+	}
 
-	return nil, f(ctx, params)
+	return nil, h.f(ctx, params)
+}
+
+func (h notifyHandler[I]) signature() handlerSignature {
+	return handlerSignature{
+		params: []reflect.Type{reflect.TypeFor[I]()},
+		conf:   h.conf,
+	}
+}
+
+// HandlerOption is a type for options of handler constructors such as `Call2`.
+type HandlerOption func(*handlerConfig)
+
+type handlerConfig struct {
+	paramNames  []string
+	summary     string
+	description string
+	errors      []Error
+	strict      bool
+}
+
+func newHandlerConfig(opts []HandlerOption) handlerConfig {
+	var conf handlerConfig
+	for _, opt := range opts {
+		opt(&conf)
+	}
+	return conf
 }
 
 type handlerInfo struct {
 	name    string
 	handler Handler
+
+	// sig is the signature of the handler for the OpenRPC document.
+	// It is nil if the handler does not provide it.
+	sig *handlerSignature
 }
 
 // Server is a JSON-RPC 2.0 server.
 type Server struct {
+	hmu                sync.RWMutex
 	handlers           []handlerInfo
+	fallback           Handler
+	framing            Framing
 	maxConcurrentCalls int
+	maxQueuedCalls     int
+	maxRequestSize     int64
 	semaphore          chan struct{}
+	backlog            chan struct{}
+	sequential         bool
+	cancelMethod       string
+	middlewares        []Middleware
+	chain              Handler
+	recovery           RecoveryStrategy
+	naming             NamingStrategy
+	openrpcInfo        OpenRPCInfo
+	strict             bool
+
+	mu         sync.Mutex
+	listeners  map[Listener]struct{}
+	conns      map[*serverConn]struct{}
+	inShutdown atomic.Bool
+	active     int           // active is the number of running requests. Protected by mu.
+	idle       chan struct{} // idle is closed when active becomes 0. Protected by mu.
+}
+
+// serverConn is a connection that is being served by `Server.ServeForOne`.
+type serverConn struct {
+	rw     io.ReadWriter
+	cancel context.CancelFunc
+}
+
+// close cancels the context of the connection, and closes the connection if possible.
+func (c *serverConn) close() {
+	c.cancel()
+	if closer, ok := c.rw.(io.Closer); ok {
+		closer.Close()
+	}
 }
 
 // NewServer creates a new JSON-RPC 2.0 server.
 func NewServer(opts ...ServerOption) *Server {
 	s := &Server{
+		framing:            StreamFraming{},
 		maxConcurrentCalls: 100,
+		maxQueuedCalls:     1000,
+		maxRequestSize:     10 << 20,
+		cancelMethod:       DefaultCancelMethod,
+		openrpcInfo:        OpenRPCInfo{Title: "JSON-RPC 2.0 server", Version: "0.0.0"},
+		listeners:          make(map[Listener]struct{}),
+		conns:              make(map[*serverConn]struct{}),
 	}
 	for _, opt := range opts {
 		opt(s)
 	}
 	s.semaphore = make(chan struct{}, s.maxConcurrentCalls)
+	s.backlog = make(chan struct{}, s.maxConcurrentCalls+s.maxQueuedCalls)
+	s.chain = applyMiddlewares(HandlerFunc(s.dispatch), s.middlewares)
 	return s
 }
 
@@ -81,6 +188,10 @@ type ServerOption func(*Server)
 // WithMaxConcurrentCalls specifies the maximum number of concurrent calls the server can handle.
 // If this option is not specified, the default value is 100.
 //
+// Requests over the limit wait for running calls to return.
+// The peer can cancel them while waiting, as well as running ones.
+// The number of waiting requests is limited by `WithMaxQueuedCalls`.
+//
 // maxConcurrent must be greater than 0.
 func WithMaxConcurrentCalls(maxConcurrent int) ServerOption {
 	if maxConcurrent <= 0 {
@@ -91,42 +202,182 @@ func WithMaxConcurrentCalls(maxConcurrent int) ServerOption {
 	}
 }
 
+// WithMaxQueuedCalls specifies the maximum number of requests that wait for running calls to return.
+// If this option is not specified, the default value is 1000.
+//
+// Requests over the limit are rejected with `ErrServerBusy` without calling handlers, and notifications over the limit are discarded.
+// A batch is counted as a single request.
+// This option does not affect `WithSequentialDispatch`, because the server does not read the next request until the current one returns.
+//
+// maxQueued must not be negative.
+func WithMaxQueuedCalls(maxQueued int) ServerOption {
+	if maxQueued < 0 {
+		panic("maxQueued must not be negative")
+	}
+	return func(s *Server) {
+		s.maxQueuedCalls = maxQueued
+	}
+}
+
+// WithSequentialDispatch makes the server handle requests one by one in the received order, including requests in a batch.
+//
+// By default, the server handles requests from the same connection concurrently,
+// so a slow method does not block other requests.
+// This option is useful for peers that require in-order processing.
+func WithSequentialDispatch() ServerOption {
+	return func(s *Server) {
+		s.sequential = true
+	}
+}
+
+// WithCancelMethod specifies the method name for cancellation notifications.
+// If this option is not specified, `DefaultCancelMethod` is used.
+// An empty string disables the cancellation.
+//
+// When the server receives a cancellation notification with `CancelParams`,
+// it cancels the context of the running request that has the ID,
+// and responds `ErrRequestCancelled` to the request.
+// The cancellation works only for requests from the same connection.
+func WithCancelMethod(name string) ServerOption {
+	return func(s *Server) {
+		s.cancelMethod = name
+	}
+}
+
+// WithMiddleware adds middlewares that are applied to all requests, including requests for unknown methods.
+// The first middleware is the outermost one.
+//
+// Please use `Server.On` to add middlewares for a specific method.
+func WithMiddleware(mws ...Middleware) ServerOption {
+	return func(s *Server) {
+		s.middlewares = append(s.middlewares, mws...)
+	}
+}
+
+// RecoveryStrategy is a strategy to recover from malformed data in a stream.
+type RecoveryStrategy int
+
+const (
+	// SkipMalformed skips malformed data to the next frame and continues serving the connection.
+	// How far the data is skipped depends on the `Framing`.
+	SkipMalformed RecoveryStrategy = iota
+
+	// CloseOnMalformed stops serving the connection after malformed data.
+	// The connection is closed if it implements io.Closer.
+	CloseOnMalformed
+)
+
+// WithRecoveryStrategy specifies how to recover from malformed data in a stream.
+// If this option is not specified, `SkipMalformed` is used.
+//
+// In both strategies, the server responds `ErrParseError` for malformed data.
+// This option does not affect invalid requests in valid JSON, because they do not break the stream.
+func WithRecoveryStrategy(strategy RecoveryStrategy) ServerOption {
+	return func(s *Server) {
+		s.recovery = strategy
+	}
+}
+
+// WithFraming specifies the framing to split the stream into messages.
+// If this option is not specified, `StreamFraming` is used.
+//
+// This option does not affect `Server.ServeHTTP`.
+func WithFraming(f Framing) ServerOption {
+	return func(s *Server) {
+		s.framing = f
+	}
+}
+
 // ServeJSONRPC2 implements the Handler interface.
 //
 // Do not call this method directly.
 func (s *Server) ServeJSONRPC2(ctx context.Context, r RawRequest) (any, error) {
-	idx := sort.Search(len(s.handlers), func(i int) bool {
-		return s.handlers[i].name >= r.Method
-	})
+	return s.chain.ServeJSONRPC2(ctx, r)
+}
+
+// dispatch calls the handler for the method without the global middlewares.
+func (s *Server) dispatch(ctx context.Context, r RawRequest) (any, error) {
+	if s.strict {
+		ctx = withStrictParams(ctx)
+	}
 
-	if idx >= len(s.handlers) || s.handlers[idx].name != r.Method {
+	h, ok := s.lookup(r.Method)
+	if !ok {
+		if r.Method == DiscoverMethod {
+			return s.OpenRPC(), nil
+		}
+		if s.fallback != nil {
+			return s.fallback.ServeJSONRPC2(ctx, r)
+		}
 		return nil, ErrMethodNotFound
 	}
 
-	return s.handlers[idx].handler.ServeJSONRPC2(ctx, r)
+	return h.ServeJSONRPC2(ctx, r)
+}
+
+// lookup returns the handler registered for the method.
+func (s *Server) lookup(name string) (Handler, bool) {
+	s.hmu.RLock()
+	defer s.hmu.RUnlock()
+
+	idx := sort.Search(len(s.handlers), func(i int) bool {
+		return s.handlers[i].name >= name
+	})
+	if idx >= len(s.handlers) || s.handlers[idx].name != name {
+		return nil, false
+	}
+	return s.handlers[idx].handler, true
 }
 
 // On registers a new handler for a method.
+// It is safe to call this method while the server is serving.
 //
 // If the handler returns `Error` struct as an error, the server sends an error as-is to the client.
-func (s *Server) On(name string, m Handler) {
+//
+// The `mws` parameter is middlewares only for this method.
+// They are applied inside of the middlewares specified by `WithMiddleware`.
+func (s *Server) On(name string, m Handler, mws ...Middleware) {
+	var sig *handlerSignature
+	if h, ok := m.(signatureHandler); ok {
+		x := h.signature()
+		sig = &x
+	}
+
+	m = applyMiddlewares(m, mws)
+
+	s.hmu.Lock()
+	defer s.hmu.Unlock()
+
 	idx := sort.Search(len(s.handlers), func(i int) bool {
 		return s.handlers[i].name >= name
 	})
 
 	if idx < len(s.handlers) && s.handlers[idx].name == name {
-		s.handlers[idx].handler = m
+		s.handlers[idx] = handlerInfo{name, m, sig}
 	} else {
 		s.handlers = append(s.handlers, handlerInfo{})
 		copy(s.handlers[idx+1:], s.handlers[idx:])
-		s.handlers[idx] = handlerInfo{name, m}
+		s.handlers[idx] = handlerInfo{name, m, sig}
 	}
 }
 
 // call invokes a single request and returns the response.
 // The return type uses a pointer to any to make differentation between nil and zero values.
 func (s *Server) call(ctx context.Context, r RawRequest) *Response[*any] {
-	result, err := s.ServeJSONRPC2(ctx, r)
+	if s.handleCancel(ctx, r) {
+		if r.ID == nil {
+			return nil
+		}
+		var result any
+		return &Response[*any]{Jsonrpc: VersionValue, Result: &result, ID: r.ID}
+	}
+
+	// The request may be cancelled while waiting for the semaphore.
+	var result any
+	err := context.Cause(ctx)
+	if err == nil {
+		result, err = s.ServeJSONRPC2(ctx, r)
+	}
 	if r.ID == nil {
 		return nil
 	}
@@ -137,7 +388,9 @@ func (s *Server) call(ctx context.Context, r RawRequest) *Response[*any] {
 	}
 
 	var errRes Error
-	if errors.As(err, &errRes) {
+	if errors.Is(context.Cause(ctx), errCancelledByPeer) {
+		resp.Error = &ErrRequestCancelled
+	} else if errors.As(err, &errRes) {
 		resp.Error = &errRes
 	} else if err != nil {
 		resp.Error = &Error{Code: InternalErrorCode, Message: "Internal error"}
@@ -148,28 +401,99 @@ func (s *Server) call(ctx context.Context, r RawRequest) *Response[*any] {
 	return &resp
 }
 
-func (s *Server) callAll(ctx context.Context, rw io.ReadWriter, rs messageList[RawRequest]) {
+// acquire takes the semaphore for a request.
+// It returns false without taking the semaphore if `ctx` is done while waiting.
+func (s *Server) acquire(ctx context.Context) bool {
+	select {
+	case s.semaphore <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// reserve takes a slot of the backlog for a request that is handled in a new goroutine.
+// It returns false without waiting if the backlog is full.
+// Please call release when the request is done.
+func (s *Server) reserve() bool {
+	select {
+	case s.backlog <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// release returns a slot of the backlog that is taken by reserve.
+func (s *Server) release() {
+	<-s.backlog
+}
+
+// writeBusy writes `ErrServerBusy` responses for requests that are rejected because the backlog is full.
+// The `invalids` are written together, the same as `callAllAndWrite`.
+func writeBusy(w FrameWriter, rs messageList[RawRequest], invalids []Response[*any]) {
+	res := messageList[Response[*any]]{IsBatch: rs.IsBatch, Messages: invalids}
+	for _, r := range rs.Messages {
+		if r.ID != nil {
+			res.Messages = append(res.Messages, Response[*any]{Jsonrpc: VersionValue, Error: &ErrServerBusy, ID: r.ID})
+		}
+	}
+	if len(res.Messages) > 0 {
+		writeMessage(w, res)
+	}
+}
+
+// callAll invokes requests and returns responses for them.
+// The result does not include responses for notifications.
+//
+// Requests in a batch are registered by trackRequest in this method.
+// A single request should be registered by the caller before waiting for the semaphore.
+func (s *Server) callAll(ctx context.Context, rs messageList[RawRequest]) messageList[Response[*any]] {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	if len(rs.Messages) == 0 {
+		return messageList[Response[*any]]{IsBatch: rs.IsBatch}
+	}
+
 	if !rs.IsBatch {
 		r := s.call(ctx, rs.Messages[0])
-		if r != nil {
-			r.WriteTo(rw)
+		if r == nil {
+			return messageList[Response[*any]]{}
 		}
-		return
+		return messageList[Response[*any]]{Messages: []Response[*any]{*r}}
+	}
+
+	// Register all requests before waiting for the semaphore, so that the peer can cancel queued ones.
+	ctxs := make([]context.Context, len(rs.Messages))
+	for i, req := range rs.Messages {
+		var done func()
+		ctxs[i], done = trackRequest(ctx, req)
+		defer done()
+	}
+
+	if s.sequential {
+		results := make([]Response[*any], 0, len(rs.Messages))
+		for i, req := range rs.Messages {
+			if resp := s.call(ctxs[i], req); resp != nil {
+				results = append(results, *resp)
+			}
+		}
+		return messageList[Response[*any]]{IsBatch: true, Messages: results}
 	}
 
 	ch := make(chan *Response[*any], len(rs.Messages))
 
-	for _, req := range rs.Messages {
-		s.semaphore <- struct{}{}
+	for i, req := range rs.Messages {
+		acquired := s.acquire(ctxs[i])
 
-		go func(req RawRequest) {
-			defer func() { <-s.semaphore }()
+		go func(ctx context.Context, req RawRequest) {
+			if acquired {
+				defer func() { <-s.semaphore }()
+			}
 
 			ch <- s.call(ctx, req)
-		}(req)
+		}(ctxs[i], req)
 	}
 
 	results := make([]Response[*any], 0, len(rs.Messages))
@@ -186,37 +510,309 @@ func (s *Server) callAll(ctx context.Context, rw io.ReadWriter, rs messageList[R
 
 	close(ch)
 
-	json.NewEncoder(rw).Encode(results)
+	return messageList[Response[*any]]{IsBatch: true, Messages: results}
+}
+
+// callAllAndWrite invokes requests and writes responses to `w`.
+// The `invalids` are error responses for invalid requests in the same frame, that are written together.
+// Nothing is written if all requests are notifications.
+func (s *Server) callAllAndWrite(ctx context.Context, w FrameWriter, rs messageList[RawRequest], invalids []Response[*any]) {
+	res := s.callAll(ctx, rs)
+	res.Messages = append(invalids, res.Messages...)
+	if len(res.Messages) > 0 {
+		writeMessage(w, res)
+	}
 }
 
 // ServeForOne reads requests from the given io.ReadWriter and sends responses to it.
+//
+// Requests are handled concurrently unless `WithSequentialDispatch` is specified.
+// This method returns after all running handlers have finished.
+// Before returning, it closes `rw` if it implements io.Closer.
+//
+// If `rw` implements `AcceptedConn`, handlers can get it by `ConnFromContext`.
 func (s *Server) ServeForOne(rw io.ReadWriter) {
-	r := json.NewDecoder(rw)
+	s.serve(context.Background(), rw)
+}
 
-	ctx, cancel := context.WithCancel(context.Background())
+// serve is the same as `ServeForOne`, but the contexts of handlers are derived from `ctx`.
+// The caller should unblock reading from `rw` when `ctx` is done.
+//
+// It returns false if the server is shutting down.
+func (s *Server) serve(ctx context.Context, rw io.ReadWriter) bool {
+	r := newFrameReader(s.framing, rw)
+	w := &lockedFrameWriter{w: newFrameWriter(s.framing, rw)}
+
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	ctx = withInflightRequests(ctx)
+	ctx = withConn(ctx, rw)
+
+	conn := &serverConn{rw: rw, cancel: cancel}
+	if !s.trackConn(conn, true) {
+		conn.close()
+		return false
+	}
+	defer s.trackConn(conn, false)
+	defer conn.close()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
 	for {
+		data, err := r.ReadFrame()
+		if ctx.Err() != nil {
+			return true
+		} else if err != nil && !errors.Is(err, ErrInvalidFrame) {
+			// EOF or I/O error. The stream cannot be read anymore.
+			// After EOF, the peer may still wait for responses of running requests.
+			// After other errors, the responses cannot be delivered, so cancel the handlers.
+			if !errors.Is(err, io.EOF) {
+				cancel()
+			}
+			return true
+		}
+
 		var rs messageList[RawRequest]
-		if err := r.Decode(&rs); errors.Is(err, io.EOF) {
-			return
-		} else if err != nil {
-			NewErrorResponse(NullID(), ErrInvalidRequest).WriteTo(rw)
+		var invalids []Response[*any]
+		if err == nil {
+			rs, invalids, err = decodeRequests(data)
+		}
+		if err != nil {
+			w.WriteFrame(parseErrorResponse)
+			if s.recovery == CloseOnMalformed {
+				conn.close()
+				return true
+			}
 			continue
 		}
 
-		s.callAll(ctx, rw, rs)
+		if len(rs.Messages) == 0 {
+			s.callAllAndWrite(ctx, w, rs, invalids)
+			continue
+		}
+
+		// Handle cancellation immediately, because it should not wait for the semaphore or other requests.
+		if !rs.IsBatch && rs.Messages[0].ID == nil && s.handleCancel(ctx, rs.Messages[0]) {
+			continue
+		}
+
+		// Reject the request if too many requests are waiting, so that a flooding peer cannot create goroutines without limit.
+		// The loop does not wait for free slots, so that it can keep reading cancellations.
+		if !s.sequential && !s.reserve() {
+			writeBusy(w, rs, invalids)
+			continue
+		}
+
+		// Count the request before handling, so that Shutdown cannot miss it.
+		if !s.startRequest() {
+			if !s.sequential {
+				s.release()
+			}
+			return false
+		}
+
+		// Register the request before waiting for the semaphore, so that the peer can cancel it while queued.
+		rctx, done := ctx, func() {}
+		if !rs.IsBatch {
+			rctx, done = trackRequest(ctx, rs.Messages[0])
+		}
+
+		if s.sequential {
+			s.callAllAndWrite(rctx, w, rs, invalids)
+			done()
+			s.finishRequest()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer s.release()
+			defer s.finishRequest()
+			defer done()
+
+			// The semaphore is taken here instead of the reading loop, so that the loop can read cancellations for queued requests.
+			// Each request in a batch takes the semaphore in callAll.
+			if !rs.IsBatch && s.acquire(rctx) {
+				defer func() { <-s.semaphore }()
+			}
+
+			s.callAllAndWrite(rctx, w, rs, invalids)
+		}()
 	}
 }
 
+// ErrServerClosed is returned by `Server.Serve` after a call to `Server.Shutdown` or `Server.Close`.
+var ErrServerClosed = errors.New("jsonrpc2: Server closed")
+
 // Serve accepts connections from the given listener and handles them.
+//
+// Serve always returns a non-nil error.
+// After `Server.Shutdown` or `Server.Close`, the returned error is `ErrServerClosed`.
 func (s *Server) Serve(l Listener) error {
+	if !s.trackListener(l, true) {
+		l.Close()
+		return ErrServerClosed
+	}
+	defer s.trackListener(l, false)
+
 	for {
 		rw, err := l.Accept()
 		if err != nil {
+			if s.inShutdown.Load() {
+				return ErrServerClosed
+			}
 			return err
 		}
 
 		go s.ServeForOne(rw)
 	}
 }
+
+// trackListener adds or removes a listener to be closed at shutdown.
+// It returns false if the server is already shutting down.
+func (s *Server) trackListener(l Listener, add bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if add {
+		if s.inShutdown.Load() {
+			return false
+		}
+		s.listeners[l] = struct{}{}
+	} else {
+		delete(s.listeners, l)
+	}
+	return true
+}
+
+// trackConn adds or removes a connection to be closed at shutdown.
+// It returns false if the server is already shutting down.
+func (s *Server) trackConn(c *serverConn, add bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if add {
+		if s.inShutdown.Load() {
+			return false
+		}
+		s.conns[c] = struct{}{}
+	} else {
+		delete(s.conns, c)
+	}
+	return true
+}
+
+// startRequest counts a request as running.
+// It returns false if the server is already shutting down.
+func (s *Server) startRequest() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.inShutdown.Load() {
+		return false
+	}
+	s.active++
+	return true
+}
+
+// finishRequest uncounts a request that is started by startRequest.
+func (s *Server) finishRequest() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.active--
+	if s.active == 0 && s.idle != nil {
+		close(s.idle)
+		s.idle = nil
+	}
+}
+
+// waitIdle returns a channel that is closed when no request is running.
+func (s *Server) waitIdle() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active == 0 {
+		ch := make(chan struct{})
+		close(ch)
+		return ch
+	}
+	if s.idle == nil {
+		s.idle = make(chan struct{})
+	}
+	return s.idle
+}
+
+// closeListeners closes all listeners and returns the connections being served.
+func (s *Server) closeListeners() ([]*serverConn, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inShutdown.Store(true)
+
+	var errs []error
+	for l := range s.listeners {
+		if err := l.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(s.listeners, l)
+	}
+
+	conns := make([]*serverConn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+
+	return conns, errors.Join(errs...)
+}
+
+// Shutdown gracefully shuts down the server.
+//
+// Shutdown works like `http.Server.Shutdown`.
+// It closes all listeners, cancels the contexts of all connections,
+// and then waits for running handlers to return before closing the connections.
+// Connections are closed only if they implement io.Closer.
+//
+// If the `ctx` expires before all handlers return, Shutdown closes the connections and returns the context's error.
+// Otherwise, it returns any error returned from closing the listeners.
+//
+// Once Shutdown has been called, the server cannot be reused.
+func (s *Server) Shutdown(ctx context.Context) error {
+	conns, err := s.closeListeners()
+
+	for _, c := range conns {
+		c.cancel()
+	}
+
+	defer func() {
+		for _, c := range conns {
+			c.close()
+		}
+	}()
+
+	// No request starts after closeListeners, so the server stays idle once it becomes idle.
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.waitIdle():
+	}
+
+	return err
+}
+
+// Close immediately closes all listeners and connections.
+//
+// Close does not wait for running handlers.
+// For a graceful shutdown, use `Shutdown`.
+func (s *Server) Close() error {
+	conns, err := s.closeListeners()
+
+	for _, c := range conns {
+		c.close()
+	}
+
+	return err
+}
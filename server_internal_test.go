@@ -3,7 +3,9 @@ package jsonrpc2
 import (
 	"context"
 	"encoding/json"
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -161,3 +163,44 @@ func BenchmarkServer_Call_error(b *testing.B) {
 		})
 	}
 }
+
+func TestServer_Shutdown_active(t *testing.T) {
+	server := NewServer()
+
+	if !server.startRequest() {
+		t.Fatalf("failed to start a request")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Shutdown(ctx)
+	}()
+
+	for !server.inShutdown.Load() {
+		runtime.Gosched()
+	}
+
+	if server.startRequest() {
+		t.Errorf("a request started after Shutdown")
+	}
+
+	select {
+	case err := <-done:
+		t.Fatalf("Shutdown returned before the request finished: %v", err)
+	default:
+	}
+
+	server.finishRequest()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("failed to shutdown: %s", err)
+		}
+	case <-ctx.Done():
+		t.Fatalf("Shutdown did not return after the request finished")
+	}
+}
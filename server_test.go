@@ -4,8 +4,16 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"runtime"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/macrat/go-jsonrpc2"
 )
 
@@ -42,3 +50,649 @@ func BenchmarkServer(b *testing.B) {
 		reader.ReadLine()
 	}
 }
+
+func TestServer_concurrentDispatch(t *testing.T) {
+	t.Parallel()
+
+	cli, srv := BiDirectionalPipe(t)
+	defer cli.Close()
+
+	wake := make(chan struct{})
+
+	server := jsonrpc2.NewServer()
+	server.On("sleep", jsonrpc2.Call(func(ctx context.Context, _ any) (string, error) {
+		select {
+		case <-wake:
+			return "woke up", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}))
+	server.On("wake", jsonrpc2.Call(func(ctx context.Context, _ any) (string, error) {
+		close(wake)
+		return "ok", nil
+	}))
+	go server.ServeForOne(srv)
+
+	client := jsonrpc2.NewClient(cli)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	errs := make(chan error, 1)
+	go func() {
+		var s string
+		errs <- client.Call(ctx, "sleep", nil, &s)
+	}()
+
+	// "wake" never reaches the handler if "sleep" blocks the connection.
+	var s string
+	if err := client.Call(ctx, "wake", nil, &s); err != nil {
+		t.Fatalf("failed to call wake: %s", err)
+	}
+
+	if err := <-errs; err != nil {
+		t.Fatalf("failed to call sleep: %s", err)
+	}
+}
+
+func TestServer_sequentialDispatch(t *testing.T) {
+	t.Parallel()
+
+	cli, srv := BiDirectionalPipe(t)
+	defer cli.Close()
+
+	var received []int
+
+	server := jsonrpc2.NewServer(jsonrpc2.WithSequentialDispatch())
+	server.On("push", jsonrpc2.Notify(func(ctx context.Context, n int) error {
+		// Yield to other goroutines to make reordering likely if the server dispatches concurrently.
+		time.Sleep(time.Millisecond)
+		received = append(received, n)
+		return nil
+	}))
+	server.On("get", jsonrpc2.Call(func(ctx context.Context, _ any) ([]int, error) {
+		return received, nil
+	}))
+	go server.ServeForOne(srv)
+
+	client := jsonrpc2.NewClient(cli)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var expected []int
+	for i := 0; i < 20; i++ {
+		if err := client.Notify(ctx, "push", i); err != nil {
+			t.Fatalf("failed to notify push: %s", err)
+		}
+		expected = append(expected, i)
+	}
+
+	var got []int
+	if err := client.Call(ctx, "get", nil, &got); err != nil {
+		t.Fatalf("failed to call get: %s", err)
+	}
+
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("unexpected order:\n%s", diff)
+	}
+}
+
+// PipeListener is a Listener for testing that accepts connections created by `Dial`.
+type PipeListener struct {
+	t      *testing.T
+	ch     chan io.ReadWriter
+	closed chan struct{}
+	once   sync.Once
+}
+
+func NewPipeListener(t *testing.T) *PipeListener {
+	return &PipeListener{
+		t:      t,
+		ch:     make(chan io.ReadWriter),
+		closed: make(chan struct{}),
+	}
+}
+
+func (l *PipeListener) Accept() (io.ReadWriter, error) {
+	select {
+	case rw := <-l.ch:
+		return rw, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *PipeListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *PipeListener) Dial() *ReadWriteCloser {
+	cli, srv := BiDirectionalPipe(l.t)
+	l.ch <- srv
+	return cli
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+
+	server := jsonrpc2.NewServer()
+	server.On("wait", jsonrpc2.Call(func(ctx context.Context, _ any) (string, error) {
+		close(started)
+		<-ctx.Done()
+		close(canceled)
+
+		// Simulate a cleanup that takes a while.
+		time.Sleep(50 * time.Millisecond)
+		return "done", nil
+	}))
+
+	l := NewPipeListener(t)
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(l)
+	}()
+
+	cli := l.Dial()
+	defer cli.Close()
+
+	client := jsonrpc2.NewClient(cli)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	called := make(chan error, 1)
+	var result string
+	go func() {
+		called <- client.Call(ctx, "wait", nil, &result)
+	}()
+
+	<-started
+
+	if err := server.Shutdown(ctx); err != nil {
+		t.Fatalf("failed to shutdown: %s", err)
+	}
+
+	select {
+	case <-canceled:
+	default:
+		t.Errorf("handler context was not canceled")
+	}
+
+	if err := <-called; err != nil {
+		t.Errorf("failed to call wait: %s", err)
+	} else if result != "done" {
+		t.Errorf("unexpected result: %q", result)
+	}
+
+	if err := <-served; !errors.Is(err, jsonrpc2.ErrServerClosed) {
+		t.Errorf("unexpected error from Serve: %v", err)
+	}
+
+	if err := server.Serve(NewPipeListener(t)); !errors.Is(err, jsonrpc2.ErrServerClosed) {
+		t.Errorf("unexpected error from Serve after shutdown: %v", err)
+	}
+}
+
+func TestServer_Shutdown_timeout(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	server := jsonrpc2.NewServer()
+	server.On("stuck", jsonrpc2.Call(func(ctx context.Context, _ any) (string, error) {
+		close(started)
+		<-release
+		return "done", nil
+	}))
+
+	l := NewPipeListener(t)
+	go server.Serve(l)
+
+	cli := l.Dial()
+	defer cli.Close()
+
+	client := jsonrpc2.NewClient(cli)
+	defer client.Close()
+
+	go client.Call(context.Background(), "stuck", nil, nil)
+
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected error from Shutdown: %v", err)
+	}
+}
+
+func TestServer_Close(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+
+	server := jsonrpc2.NewServer()
+	server.On("wait", jsonrpc2.Call(func(ctx context.Context, _ any) (string, error) {
+		close(started)
+		<-ctx.Done()
+		return "", ctx.Err()
+	}))
+
+	l := NewPipeListener(t)
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(l)
+	}()
+
+	cli := l.Dial()
+	defer cli.Close()
+
+	client := jsonrpc2.NewClient(cli)
+	defer client.Close()
+
+	go client.Call(context.Background(), "wait", nil, nil)
+
+	<-started
+
+	if err := server.Close(); err != nil {
+		t.Fatalf("failed to close: %s", err)
+	}
+
+	if err := <-served; !errors.Is(err, jsonrpc2.ErrServerClosed) {
+		t.Errorf("unexpected error from Serve: %v", err)
+	}
+
+	// The connection is closed by the server.
+	if _, err := cli.Write([]byte("{}")); err == nil {
+		t.Errorf("expected error on closed connection")
+	}
+}
+
+func TestServer_cancelRequest(t *testing.T) {
+	t.Parallel()
+
+	cli, srv := BiDirectionalPipe(t)
+	defer cli.Close()
+
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+
+	server := jsonrpc2.NewServer(jsonrpc2.WithCancelMethod("cancel"))
+	server.On("wait", jsonrpc2.Call(func(ctx context.Context, _ any) (string, error) {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return "", ctx.Err()
+	}))
+	go server.ServeForOne(srv)
+
+	client := jsonrpc2.NewClient(cli, jsonrpc2.WithClientCancelMethod("cancel"))
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	if err := client.Call(ctx, "wait", nil, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("handler was not cancelled")
+	}
+}
+
+func TestServer_cancelRequest_response(t *testing.T) {
+	t.Parallel()
+
+	cli, srv := BiDirectionalPipe(t)
+	defer cli.Close()
+
+	started := make(chan struct{})
+
+	server := jsonrpc2.NewServer()
+	server.On("wait", jsonrpc2.Call(func(ctx context.Context, _ any) (string, error) {
+		close(started)
+		<-ctx.Done()
+		return "", ctx.Err()
+	}))
+	go server.ServeForOne(srv)
+
+	go func() {
+		cli.Write([]byte(`{"jsonrpc":"2.0","method":"wait","params":null,"id":"abc"}`))
+		<-started
+		cli.Write([]byte(`{"jsonrpc":"2.0","method":"$/cancelRequest","params":{"id":123}}`))
+		cli.Write([]byte(`{"jsonrpc":"2.0","method":"$/cancelRequest","params":{"id":"abc"}}`))
+	}()
+
+	var res jsonrpc2.Response[any]
+	if err := json.NewDecoder(cli).Decode(&res); err != nil {
+		t.Fatalf("failed to read response: %s", err)
+	}
+
+	if res.Error == nil || res.Error.Code != jsonrpc2.RequestCancelledCode {
+		t.Errorf("unexpected response: %v", res)
+	}
+	if res.ID == nil || res.ID.String() != `"abc"` {
+		t.Errorf("unexpected ID: %v", res.ID)
+	}
+}
+
+func TestServer_cancelRequest_queued(t *testing.T) {
+	t.Parallel()
+
+	cli, srv := BiDirectionalPipe(t)
+	defer cli.Close()
+
+	started := make(chan string, 2)
+	release := make(chan struct{})
+
+	server := jsonrpc2.NewServer(jsonrpc2.WithMaxConcurrentCalls(1))
+	server.On("wait", jsonrpc2.Call(func(ctx context.Context, name string) (string, error) {
+		started <- name
+		<-release
+		return name, nil
+	}))
+	go server.ServeForOne(srv)
+
+	go func() {
+		cli.Write([]byte(`{"jsonrpc":"2.0","method":"wait","params":"running","id":1}`))
+		<-started
+
+		// The second request waits for the semaphore, and the peer cancels it.
+		cli.Write([]byte(`{"jsonrpc":"2.0","method":"wait","params":"queued","id":2}`))
+		cli.Write([]byte(`{"jsonrpc":"2.0","method":"$/cancelRequest","params":{"id":2}}`))
+	}()
+
+	// The response for the queued request does not come if the cancellation is not handled.
+	timer := time.AfterFunc(10*time.Second, func() { cli.Close() })
+	defer timer.Stop()
+
+	dec := json.NewDecoder(cli)
+
+	var res jsonrpc2.Response[any]
+	if err := dec.Decode(&res); err != nil {
+		t.Fatalf("failed to read response: %s", err)
+	}
+	if res.Error == nil || res.Error.Code != jsonrpc2.RequestCancelledCode || res.ID.String() != "2" {
+		t.Errorf("unexpected response for the queued request: %v", res)
+	}
+
+	close(release)
+
+	res = jsonrpc2.Response[any]{}
+	if err := dec.Decode(&res); err != nil {
+		t.Fatalf("failed to read response: %s", err)
+	}
+	if res.Error != nil || res.Result != "running" || res.ID.String() != "1" {
+		t.Errorf("unexpected response for the running request: %v", res)
+	}
+
+	select {
+	case name := <-started:
+		t.Errorf("the handler for the cancelled request was called: %s", name)
+	default:
+	}
+}
+
+// floodRequests sends many requests to a server that allows 2 running and 3 queued calls, whose handlers block until `release` is closed.
+// It checks that the excess requests are rejected, and the number of goroutines does not grow with the number of requests.
+//
+// The `before` is the number of goroutines before starting the server.
+func floodRequests(t *testing.T, cli io.ReadWriteCloser, release chan struct{}, before int) {
+	t.Helper()
+
+	const (
+		N        = 200
+		accepted = 2 + 3
+	)
+
+	go func() {
+		for i := 1; i <= N; i++ {
+			fmt.Fprintf(cli, `{"jsonrpc":"2.0","method":"wait","params":%d,"id":%d}`, i, i)
+		}
+	}()
+
+	timer := time.AfterFunc(10*time.Second, func() { cli.Close() })
+	defer timer.Stop()
+
+	dec := json.NewDecoder(cli)
+
+	for i := 0; i < N-accepted; i++ {
+		var res jsonrpc2.Response[any]
+		if err := dec.Decode(&res); err != nil {
+			t.Fatalf("failed to read response: %s", err)
+		}
+		if res.Error == nil || res.Error.Code != jsonrpc2.ServerBusyCode {
+			t.Fatalf("unexpected response for an excess request: %v", res)
+		}
+	}
+
+	// The server, the handlers, and a few goroutines of the test are running, regardless of the number of requests.
+	if n := runtime.NumGoroutine() - before; n > accepted+10 {
+		t.Errorf("too many goroutines are running: %d", n)
+	}
+
+	close(release)
+
+	for i := 0; i < accepted; i++ {
+		var res jsonrpc2.Response[int]
+		if err := dec.Decode(&res); err != nil {
+			t.Fatalf("failed to read response: %s", err)
+		}
+		if res.Error != nil || res.ID.String() != fmt.Sprint(res.Result) || res.Result > accepted {
+			t.Errorf("unexpected response for an accepted request: %v", res)
+		}
+	}
+}
+
+// This test does not run in parallel, because it counts goroutines.
+func TestServer_flood(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	cli, srv := BiDirectionalPipe(t)
+	defer cli.Close()
+
+	release := make(chan struct{})
+
+	server := jsonrpc2.NewServer(jsonrpc2.WithMaxConcurrentCalls(2), jsonrpc2.WithMaxQueuedCalls(3))
+	server.On("wait", jsonrpc2.Call(func(ctx context.Context, n int) (int, error) {
+		<-release
+		return n, nil
+	}))
+	go server.ServeForOne(srv)
+
+	floodRequests(t, cli, release, before)
+}
+
+func TestServer_ServeForOne_malformed(t *testing.T) {
+	t.Parallel()
+
+	const (
+		result3        = `{"jsonrpc":"2.0","result":3,"id":1}`
+		result7        = `{"jsonrpc":"2.0","result":7,"id":2}`
+		parseError     = `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`
+		invalidInBatch = `[{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"request must be an object"},"id":null},{"jsonrpc":"2.0","result":3,"id":1}]`
+		emptyBatch     = `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"batch must not be empty"},"id":null}`
+		invalidID      = `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"Invalid ID: ID have to be either integer, string, or null but got \"1.5\""},"id":null}`
+		noMethod       = `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"\"method\" must be a non-empty string"},"id":3}`
+	)
+
+	add1 := `{"jsonrpc":"2.0","method":"add","params":[1,2],"id":1}`
+	add2 := `{"jsonrpc":"2.0","method":"add","params":[3,4],"id":2}`
+
+	tests := []struct {
+		Name     string
+		Framing  jsonrpc2.Framing
+		Strategy jsonrpc2.RecoveryStrategy
+		Input    string
+		Output   []string
+		Closed   bool
+	}{
+		{
+			Name:     "stream-skip",
+			Framing:  jsonrpc2.StreamFraming{},
+			Strategy: jsonrpc2.SkipMalformed,
+			Input:    add1 + ` abc {bad} [1,` + add1 + `] [] {"jsonrpc":"2.0","method":"add","id":1.5} {"jsonrpc":"2.0","id":3} ` + add2,
+			Output:   []string{result3, parseError, parseError, invalidInBatch, emptyBatch, invalidID, noMethod, result7},
+		},
+		{
+			Name:     "newline-skip",
+			Framing:  jsonrpc2.NewlineFraming{},
+			Strategy: jsonrpc2.SkipMalformed,
+			Input:    add1 + "\n" + `{"jsonrpc":"2.0","method":"add","params":[1,` + "\n" + add2 + "\n",
+			Output:   []string{result3, parseError, result7},
+		},
+		{
+			Name:     "header-skip",
+			Framing:  jsonrpc2.HeaderFraming{},
+			Strategy: jsonrpc2.SkipMalformed,
+			Input:    "Content-Length: xxx\r\n\r\n" + fmt.Sprintf("Content-Length: %d\r\n\r\n", len(add2)) + add2,
+			Output:   []string{parseError, result7},
+		},
+		{
+			Name:     "stream-close",
+			Framing:  jsonrpc2.StreamFraming{},
+			Strategy: jsonrpc2.CloseOnMalformed,
+			Input:    add1 + ` {bad} ` + add2,
+			Output:   []string{result3, parseError},
+			Closed:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			cli, srv := BiDirectionalPipe(t)
+			defer cli.Close()
+
+			server := jsonrpc2.NewServer(
+				jsonrpc2.WithFraming(tt.Framing),
+				jsonrpc2.WithRecoveryStrategy(tt.Strategy),
+				jsonrpc2.WithSequentialDispatch(),
+			)
+			server.On("add", jsonrpc2.Call(func(ctx context.Context, xs []int) (int, error) {
+				return xs[0] + xs[1], nil
+			}))
+
+			done := make(chan struct{})
+			go func() {
+				server.ServeForOne(srv)
+				close(done)
+			}()
+
+			go func() {
+				cli.Write([]byte(tt.Input))
+			}()
+
+			r := tt.Framing.NewReader(cli)
+			for i, want := range tt.Output {
+				got, err := r.ReadFrame()
+				if err != nil {
+					t.Fatalf("failed to read response %d: %s", i, err)
+				}
+				if string(got) != want {
+					t.Errorf("unexpected response %d:\nwant: %s\n got: %s", i, want, got)
+				}
+			}
+
+			if tt.Closed {
+				select {
+				case <-done:
+				case <-time.After(10 * time.Second):
+					t.Fatalf("server did not stop")
+				}
+			}
+		})
+	}
+}
+
+func TestServer_ServeForOne_readError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name      string
+		Err       error
+		Cancelled bool
+	}{
+		// After EOF, the peer may still wait for responses, so handlers keep running.
+		{"eof", nil, false},
+		// After other errors, responses cannot be delivered, so handlers are cancelled.
+		{"broken", errors.New("connection reset"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			entered := make(chan struct{})
+			release := make(chan struct{})
+			cancelled := make(chan bool, 1)
+
+			server := jsonrpc2.NewServer()
+			server.On("wait", jsonrpc2.Notify(func(ctx context.Context, _ any) error {
+				close(entered)
+				select {
+				case <-ctx.Done():
+					cancelled <- true
+				case <-release:
+					cancelled <- false
+				}
+				return nil
+			}))
+
+			pr, pw := io.Pipe()
+			done := make(chan struct{})
+			go func() {
+				server.ServeForOne(struct {
+					io.Reader
+					io.Writer
+				}{pr, io.Discard})
+				close(done)
+			}()
+
+			pw.Write([]byte(`{"jsonrpc":"2.0","method":"wait","params":null}`))
+			<-entered
+			pw.CloseWithError(tt.Err)
+
+			if !tt.Cancelled {
+				time.Sleep(100 * time.Millisecond)
+				close(release)
+			}
+
+			select {
+			case got := <-cancelled:
+				if got != tt.Cancelled {
+					t.Errorf("expected cancelled=%v but got %v", tt.Cancelled, got)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("the handler did not return")
+			}
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("ServeForOne did not return")
+			}
+		})
+	}
+}
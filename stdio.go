@@ -0,0 +1,207 @@
+package jsonrpc2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// ServeStdio reads requests from os.Stdin and sends responses to os.Stdout.
+//
+// This is useful for servers that run as a child process, such as language servers.
+// When os.Stdin reaches EOF, ServeStdio waits for running handlers to finish and returns nil.
+// If `ctx` is done, ServeStdio stops reading, waits for running handlers, and returns the context's error.
+// After `Server.Shutdown` or `Server.Close`, the returned error is `ErrServerClosed`.
+//
+// ServeStdio does not close os.Stdin and os.Stdout.
+// os.Stdin is read in a background goroutine that stays until the next read from os.Stdin returns.
+func (s *Server) ServeStdio(ctx context.Context) error {
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := io.Copy(pw, os.Stdin)
+		pw.CloseWithError(err)
+	}()
+
+	rw := &stdio{PipeReader: pr, out: os.Stdout}
+
+	stop := context.AfterFunc(ctx, func() {
+		rw.Close()
+	})
+	defer stop()
+
+	if !s.serve(ctx, rw) || s.inShutdown.Load() {
+		return ErrServerClosed
+	}
+	return ctx.Err()
+}
+
+// stdio is an io.ReadWriteCloser for `ServeStdio`.
+// Close stops reading, but it does not close os.Stdin.
+type stdio struct {
+	*io.PipeReader
+	out io.Writer
+}
+
+func (s *stdio) Write(p []byte) (int, error) {
+	return s.out.Write(p)
+}
+
+// WithStderrLogger specifies the logger for stderr of the process started by `NewProcessClient`.
+// If this option is not specified, slog.Default() is used.
+//
+// Each line of stderr is logged at Info level.
+func WithStderrLogger(logger *slog.Logger) ClientOption {
+	return func(c *clientConfig) {
+		c.stderrLogger = logger
+	}
+}
+
+// WithProcessGracePeriod specifies how long `Client.Close` waits for the process started by `NewProcessClient` to exit.
+// If this option is not specified, the default value is 5 seconds.
+//
+// The process is killed if it does not exit in this period after its stdin is closed.
+func WithProcessGracePeriod(d time.Duration) ClientOption {
+	return func(c *clientConfig) {
+		c.gracePeriod = d
+	}
+}
+
+// NewProcessClient starts the command, and creates a client that communicates with it through stdin and stdout.
+//
+// The `cmd` must not be started yet, and its Stdin, Stdout, and Stderr must be nil, otherwise an error is returned.
+// Each line of stderr of the process is forwarded to the logger specified by `WithStderrLogger`.
+//
+// `Client.Close` closes stdin of the process and waits for it to exit.
+// If the process does not exit in the period specified by `WithProcessGracePeriod`, it is killed.
+// If the process exits by itself, the client stops with `*ConnectionError`.
+func NewProcessClient(cmd *exec.Cmd, opts ...ClientOption) (*Client, error) {
+	// Check them before StdinPipe, so that cmd is not modified on errors.
+	if cmd.Stdout != nil {
+		return nil, errors.New("jsonrpc2: Stdout already set")
+	}
+	if cmd.Stderr != nil {
+		return nil, errors.New("jsonrpc2: Stderr already set")
+	}
+
+	conf := newClientConfig(opts)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	// Use os.Pipe instead of cmd.StdoutPipe, because cmd.Wait closes the pipe before all responses are read.
+	stdout, w, err := os.Pipe()
+	if err != nil {
+		stdin.Close()
+		return nil, err
+	}
+	cmd.Stdout = w
+
+	stderr := &lineLogger{logger: conf.stderrLogger, path: cmd.Path}
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		stdin.Close()
+		stdout.Close()
+		w.Close()
+		return nil, err
+	}
+	w.Close()
+	stderr.setPID(cmd.Process.Pid)
+
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		stderr.flush()
+		close(exited)
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client := newClient(conf, newFrameReader(conf.framing, stdout), newFrameWriter(conf.framing, stdin), func() {
+		cancel()
+		stdin.Close()
+
+		select {
+		case <-exited:
+		case <-time.After(conf.gracePeriod):
+			cmd.Process.Kill()
+			<-exited
+		}
+
+		stdout.Close()
+	})
+
+	go client.run(ctx)
+
+	return client, nil
+}
+
+// lineLogger is an io.Writer that logs each line.
+type lineLogger struct {
+	logger *slog.Logger
+	path   string
+	pid    int
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (l *lineLogger) setPID(pid int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.pid = pid
+}
+
+// maxLineLength is the maximum length of a line for lineLogger.
+// Longer lines are split into multiple logs.
+const maxLineLength = 64 * 1024
+
+func (l *lineLogger) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.buf = append(l.buf, p...)
+	for {
+		i := bytes.IndexByte(l.buf, '\n')
+		if i < 0 {
+			if len(l.buf) >= maxLineLength {
+				l.log(l.buf)
+				l.buf = l.buf[:0]
+			}
+			return len(p), nil
+		}
+		l.log(bytes.TrimSuffix(l.buf[:i], []byte("\r")))
+		l.buf = l.buf[i+1:]
+	}
+}
+
+// flush logs the last line that does not end with a newline.
+func (l *lineLogger) flush() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.buf) > 0 {
+		l.log(l.buf)
+		l.buf = nil
+	}
+}
+
+func (l *lineLogger) log(line []byte) {
+	logger := l.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.LogAttrs(context.Background(), slog.LevelInfo, "jsonrpc2: process stderr",
+		slog.String("path", l.path),
+		slog.Int("pid", l.pid),
+		slog.String("line", string(line)),
+	)
+}
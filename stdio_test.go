@@ -0,0 +1,202 @@
+package jsonrpc2_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/macrat/go-jsonrpc2"
+)
+
+// TestStdioHelperProcess is not a real test, but a server that is started by tests for `NewProcessClient`.
+func TestStdioHelperProcess(t *testing.T) {
+	if os.Getenv("JSONRPC2_STDIO_HELPER") != "1" {
+		return
+	}
+
+	server := jsonrpc2.NewServer()
+	server.On("sum", jsonrpc2.Call(func(ctx context.Context, xs []int) (int, error) {
+		sum := 0
+		for _, x := range xs {
+			sum += x
+		}
+		return sum, nil
+	}))
+	server.On("warn", jsonrpc2.Notify(func(ctx context.Context, msg string) error {
+		fmt.Fprintln(os.Stderr, msg)
+		return nil
+	}))
+	server.On("hang", jsonrpc2.Notify(func(ctx context.Context, _ any) error {
+		select {}
+	}))
+
+	if err := server.ServeStdio(context.Background()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func helperCommand() *exec.Cmd {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestStdioHelperProcess$")
+	cmd.Env = append(os.Environ(), "JSONRPC2_STDIO_HELPER=1")
+	return cmd
+}
+
+type SyncBuffer struct {
+	sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *SyncBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *SyncBuffer) String() string {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.String()
+}
+
+func TestNewProcessClient(t *testing.T) {
+	t.Parallel()
+
+	var logs SyncBuffer
+	cmd := helperCommand()
+
+	client, err := jsonrpc2.NewProcessClient(cmd, jsonrpc2.WithStderrLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	if err != nil {
+		t.Fatalf("failed to start process: %s", err)
+	}
+
+	ctx := context.Background()
+
+	var sum int
+	if err := client.Call(ctx, "sum", []int{1, 2, 3}, &sum); err != nil {
+		t.Fatalf("failed to call: %s", err)
+	} else if sum != 6 {
+		t.Errorf("unexpected result: %d", sum)
+	}
+
+	if err := client.Notify(ctx, "warn", "hello from stderr"); err != nil {
+		t.Fatalf("failed to notify: %s", err)
+	}
+
+	// Make sure the notification is handled before closing.
+	if err := client.Call(ctx, "sum", []int{}, &sum); err != nil {
+		t.Fatalf("failed to call: %s", err)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("failed to close: %s", err)
+	}
+
+	if !cmd.ProcessState.Success() {
+		t.Errorf("the process did not exit successfully: %s", cmd.ProcessState)
+	}
+
+	if !strings.Contains(logs.String(), `line="hello from stderr"`) {
+		t.Errorf("stderr is not logged:\n%s", logs.String())
+	}
+}
+
+func TestNewProcessClient_kill(t *testing.T) {
+	t.Parallel()
+
+	cmd := helperCommand()
+
+	client, err := jsonrpc2.NewProcessClient(cmd, jsonrpc2.WithProcessGracePeriod(100*time.Millisecond))
+	if err != nil {
+		t.Fatalf("failed to start process: %s", err)
+	}
+
+	ctx := context.Background()
+
+	// ServeStdio waits for this handler after EOF, so the process does not exit by itself.
+	if err := client.Notify(ctx, "hang", nil); err != nil {
+		t.Fatalf("failed to notify: %s", err)
+	}
+
+	var sum int
+	if err := client.Call(ctx, "sum", []int{1}, &sum); err != nil {
+		t.Fatalf("failed to call: %s", err)
+	}
+
+	start := time.Now()
+	client.Close()
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("Close took too long: %s", d)
+	}
+
+	if cmd.ProcessState.Success() {
+		t.Errorf("the process should be killed")
+	}
+	if client.Err() != jsonrpc2.ErrClientClosed {
+		t.Errorf("unexpected error: %v", client.Err())
+	}
+}
+
+func TestNewProcessClient_exit(t *testing.T) {
+	t.Parallel()
+
+	// The test binary exits immediately if there are no tests to run.
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+
+	client, err := jsonrpc2.NewProcessClient(cmd, jsonrpc2.WithStderrLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	if err != nil {
+		t.Fatalf("failed to start process: %s", err)
+	}
+	defer client.Close()
+
+	select {
+	case <-client.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the client did not stop after the process exited")
+	}
+
+	if _, ok := client.Err().(*jsonrpc2.ConnectionError); !ok {
+		t.Errorf("unexpected error: %v", client.Err())
+	}
+}
+
+func TestNewProcessClient_outputAlreadySet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		Name  string
+		Setup func(cmd *exec.Cmd)
+		Error string
+	}{
+		{"stdout", func(cmd *exec.Cmd) { cmd.Stdout = io.Discard }, "jsonrpc2: Stdout already set"},
+		{"stderr", func(cmd *exec.Cmd) { cmd.Stderr = io.Discard }, "jsonrpc2: Stderr already set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := helperCommand()
+			tt.Setup(cmd)
+
+			if _, err := jsonrpc2.NewProcessClient(cmd); err == nil || err.Error() != tt.Error {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if cmd.Process != nil {
+				t.Errorf("the process was started")
+			}
+			if cmd.Stdin != nil {
+				t.Errorf("the command was modified")
+			}
+		})
+	}
+}
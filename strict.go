@@ -0,0 +1,294 @@
+package jsonrpc2
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// WithStrictParams makes all handlers decode params strictly, as the `StrictParams` option does.
+//
+// This option affects handlers created by `Call`, `Notify`, `Call2`, and so on, and handlers registered by `Server.Register`.
+func WithStrictParams() ServerOption {
+	return func(s *Server) {
+		s.strict = true
+	}
+}
+
+// StrictParams makes the handler decode params strictly.
+//
+// In the strict mode, the handler responds `ErrInvalidParams` if the params have any of the following problems.
+//
+//   - Fields of objects that do not correspond to any struct field, or names that are not listed in `ParamNames`.
+//   - Null for a type that cannot be nil, such as int, string, or struct.
+//   - Missing struct fields that do not have omitempty option in the `json` tag, or missing arguments for multi-argument handlers.
+//
+// The `Data` of the error is a `ValidationError` that lists the offending fields.
+// Types that implement json.Unmarshaler or encoding.TextUnmarshaler are not inspected.
+func StrictParams() HandlerOption {
+	return func(c *handlerConfig) {
+		c.strict = true
+	}
+}
+
+type strictKey struct{}
+
+// withStrictParams returns a context that makes handlers decode params strictly.
+func withStrictParams(ctx context.Context) context.Context {
+	return context.WithValue(ctx, strictKey{}, true)
+}
+
+func isStrict(ctx context.Context, conf handlerConfig) bool {
+	strict, _ := ctx.Value(strictKey{}).(bool)
+	return strict || conf.strict
+}
+
+// checkStrictParams checks params for a single-argument handler that takes `t`.
+func checkStrictParams(data json.RawMessage, t reflect.Type) error {
+	var c strictChecker
+
+	data = bytes.TrimSpace(data)
+	switch {
+	case t.Kind() == reflect.Struct && !hasCustomDecoder(t) && len(data) == 0:
+		c.value("params", json.RawMessage("{}"), t)
+	case t.Kind() == reflect.Struct && !hasCustomDecoder(t) && data[0] == '[':
+		c.positional("params", data, t)
+	default:
+		c.value("params", data, t)
+	}
+
+	return c.result()
+}
+
+// checkStrictArgs checks params for a multi-argument handler that takes `types`.
+func checkStrictArgs(data json.RawMessage, names []string, types ...reflect.Type) error {
+	var c strictChecker
+
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return ErrInvalidParams
+		}
+		for i, name := range names {
+			c.field("params."+name, obj[name], types[i], !nullable(types[i]))
+			delete(obj, name)
+		}
+		c.unknown("params", obj)
+	} else {
+		var arr []json.RawMessage
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &arr); err != nil {
+				return ErrInvalidParams
+			}
+		}
+		for i, t := range types {
+			var raw json.RawMessage
+			if i < len(arr) {
+				raw = arr[i]
+			}
+			c.field(fmt.Sprintf("params[%d]", i), raw, t, !nullable(t))
+		}
+	}
+
+	return c.result()
+}
+
+type strictChecker struct {
+	errs ValidationError
+}
+
+func (c *strictChecker) fail(path, message string) {
+	c.errs = append(c.errs, FieldError{Field: path, Message: message})
+}
+
+func (c *strictChecker) result() error {
+	if len(c.errs) == 0 {
+		return nil
+	}
+	err := ErrInvalidParams
+	err.Data = c.errs
+	return err
+}
+
+// field checks a value that can be missing. `raw` is nil if it is missing.
+func (c *strictChecker) field(path string, raw json.RawMessage, t reflect.Type, required bool) {
+	if raw == nil {
+		if required {
+			c.fail(path, "is required")
+		}
+		return
+	}
+	c.value(path, raw, t)
+}
+
+// value checks `data` for the type `t` recursively.
+// `data` is assumed to be successfully decoded into `t`.
+func (c *strictChecker) value(path string, data json.RawMessage, t reflect.Type) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		if !nullable(t) {
+			c.fail(path, "must not be null")
+		}
+		return
+	}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if hasCustomDecoder(t) {
+		return
+	}
+
+	switch {
+	case t.Kind() == reflect.Struct && data[0] == '{':
+		c.object(path, data, t)
+
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && data[0] == '[':
+		var arr []json.RawMessage
+		if json.Unmarshal(data, &arr) == nil {
+			for i, raw := range arr {
+				c.value(fmt.Sprintf("%s[%d]", path, i), raw, t.Elem())
+			}
+		}
+
+	case t.Kind() == reflect.Map && data[0] == '{':
+		var obj map[string]json.RawMessage
+		if json.Unmarshal(data, &obj) == nil {
+			for _, key := range sortedKeys(obj) {
+				c.value(joinPath(path, key), obj[key], t.Elem())
+			}
+		}
+	}
+}
+
+// object checks an object for the struct type `t`.
+func (c *strictChecker) object(path string, data json.RawMessage, t reflect.Type) {
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(data, &obj) != nil {
+		return
+	}
+
+	for _, f := range strictFields(t) {
+		key, ok := lookupKey(obj, f.name)
+		if ok {
+			c.value(joinPath(path, f.name), obj[key], f.typ)
+			delete(obj, key)
+		} else if f.required {
+			c.fail(joinPath(path, f.name), "is required")
+		}
+	}
+
+	c.unknown(path, obj)
+}
+
+// positional checks params by-position for the struct type `t`, as `unmarshalParams` binds them.
+func (c *strictChecker) positional(path string, data json.RawMessage, t reflect.Type) {
+	var arr []json.RawMessage
+	if json.Unmarshal(data, &arr) != nil {
+		return
+	}
+
+	for i, idx := range positionalFields(t) {
+		f := t.Field(idx)
+		name, omitempty := jsonFieldName(f)
+
+		var raw json.RawMessage
+		if i < len(arr) {
+			raw = arr[i]
+		}
+		c.field(joinPath(path, name), raw, f.Type, !omitempty)
+	}
+}
+
+// unknown reports all fields in `obj` as unknown fields.
+func (c *strictChecker) unknown(path string, obj map[string]json.RawMessage) {
+	for _, key := range sortedKeys(obj) {
+		c.fail(joinPath(path, key), "unknown field")
+	}
+}
+
+type strictField struct {
+	name     string
+	typ      reflect.Type
+	required bool
+}
+
+// strictFields returns the fields of the struct type `t` in JSON, including fields of embedded structs.
+func strictFields(t reflect.Type) []strictField {
+	var fields []strictField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if tag == "-" {
+			continue
+		}
+
+		if f.Anonymous && tag == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				fields = append(fields, strictFields(ft)...)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		name, omitempty := jsonFieldName(f)
+		fields = append(fields, strictField{name, f.Type, !omitempty})
+	}
+	return fields
+}
+
+// lookupKey finds the key for the field name in `obj`.
+// Keys are matched case-insensitively if there is no exact match, as json.Unmarshal does.
+func lookupKey(obj map[string]json.RawMessage, name string) (string, bool) {
+	if _, ok := obj[name]; ok {
+		return name, true
+	}
+	for key := range obj {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+func sortedKeys(obj map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// nullable reports whether null can be decoded into the type `t` as a meaningful value.
+func nullable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+		return true
+	}
+	return hasCustomDecoder(t)
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// hasCustomDecoder reports whether the type decodes JSON by itself.
+func hasCustomDecoder(t reflect.Type) bool {
+	return implements(t, jsonUnmarshalerType) || implements(t, textUnmarshalerType)
+}
@@ -0,0 +1,176 @@
+package jsonrpc2_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/macrat/go-jsonrpc2"
+)
+
+type StrictBase struct {
+	ID int `json:"id"`
+}
+
+type StrictParams struct {
+	StrictBase
+	Name    string            `json:"name"`
+	Note    *string           `json:"note"`
+	Limit   int               `json:"limit,omitempty"`
+	At      time.Time         `json:"at,omitempty"`
+	Items   []StrictItem      `json:"items,omitempty"`
+	Options map[string]string `json:"options,omitempty"`
+}
+
+type StrictItem struct {
+	Value int `json:"value"`
+}
+
+func TestStrictParams(t *testing.T) {
+	t.Parallel()
+
+	handler := func(ctx context.Context, p StrictParams) (string, error) {
+		return "ok", nil
+	}
+	concat := func(ctx context.Context, s string, n *int) (string, error) {
+		return "ok", nil
+	}
+
+	strictServer := jsonrpc2.NewServer(jsonrpc2.WithStrictParams())
+	strictServer.On("strict", jsonrpc2.Call(handler))
+	strictServer.On("concat", jsonrpc2.Call2(concat, jsonrpc2.ParamNames("s", "n")))
+
+	handlerServer := jsonrpc2.NewServer()
+	handlerServer.On("strict", jsonrpc2.Call(handler, jsonrpc2.StrictParams()))
+	handlerServer.On("loose", jsonrpc2.Call(handler))
+
+	tests := []struct {
+		Name   string
+		Server *jsonrpc2.Server
+		Method string
+		Params any
+		Errors jsonrpc2.ValidationError
+	}{
+		{
+			Name:   "valid",
+			Server: strictServer,
+			Method: "strict",
+			Params: map[string]any{"id": 1, "name": "alice", "note": nil, "items": []any{map[string]any{"value": 1}}},
+		},
+		{
+			Name:   "case-insensitive",
+			Server: strictServer,
+			Method: "strict",
+			Params: map[string]any{"ID": 1, "Name": "alice", "note": "hello", "at": "2024-01-02T03:04:05Z"},
+		},
+		{
+			Name:   "unknown-and-missing",
+			Server: strictServer,
+			Method: "strict",
+			Params: map[string]any{"id": 1, "nmae": "alice", "items": []any{map[string]any{"value": 1, "extra": true}}},
+			Errors: jsonrpc2.ValidationError{
+				{Field: "params.name", Message: "is required"},
+				{Field: "params.note", Message: "is required"},
+				{Field: "params.items[0].extra", Message: "unknown field"},
+				{Field: "params.nmae", Message: "unknown field"},
+			},
+		},
+		{
+			Name:   "null",
+			Server: strictServer,
+			Method: "strict",
+			Params: map[string]any{"id": nil, "name": "alice", "note": nil, "options": map[string]any{"a": nil}},
+			Errors: jsonrpc2.ValidationError{
+				{Field: "params.id", Message: "must not be null"},
+				{Field: "params.options.a", Message: "must not be null"},
+			},
+		},
+		{
+			Name:   "by-position",
+			Server: strictServer,
+			Method: "strict",
+			Params: []any{"alice"},
+			Errors: jsonrpc2.ValidationError{
+				{Field: "params.note", Message: "is required"},
+			},
+		},
+		{
+			Name:   "multi-by-name",
+			Server: strictServer,
+			Method: "concat",
+			Params: map[string]any{"n": nil, "x": 1},
+			Errors: jsonrpc2.ValidationError{
+				{Field: "params.s", Message: "is required"},
+				{Field: "params.x", Message: "unknown field"},
+			},
+		},
+		{
+			Name:   "multi-by-position",
+			Server: strictServer,
+			Method: "concat",
+			Params: []any{nil},
+			Errors: jsonrpc2.ValidationError{
+				{Field: "params[0]", Message: "must not be null"},
+			},
+		},
+		{
+			Name:   "multi-optional",
+			Server: strictServer,
+			Method: "concat",
+			Params: []any{"a"},
+		},
+		{
+			Name:   "handler-option",
+			Server: handlerServer,
+			Method: "strict",
+			Params: map[string]any{"id": 1, "name": "alice", "note": nil, "unknown": 1},
+			Errors: jsonrpc2.ValidationError{
+				{Field: "params.unknown", Message: "unknown field"},
+			},
+		},
+		{
+			Name:   "not-strict",
+			Server: handlerServer,
+			Method: "loose",
+			Params: map[string]any{"unknown": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			ts := httptest.NewServer(tt.Server)
+			defer ts.Close()
+
+			client := jsonrpc2.NewHTTPClient(ts.URL, nil)
+
+			var result string
+			err := client.Call(context.Background(), tt.Method, tt.Params, &result)
+
+			if tt.Errors == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+
+			var rpcErr *jsonrpc2.Error
+			if !errors.As(err, &rpcErr) {
+				t.Fatalf("unexpected error: %#v", err)
+			}
+			if rpcErr.Code != jsonrpc2.InvalidParamsCode {
+				t.Errorf("unexpected error code: %d", rpcErr.Code)
+			}
+
+			var want []any
+			for _, e := range tt.Errors {
+				want = append(want, map[string]any{"field": e.Field, "message": e.Message})
+			}
+			if diff := cmp.Diff(want, rpcErr.Data); diff != "" {
+				t.Errorf("unexpected error data:\n%s", diff)
+			}
+		})
+	}
+}
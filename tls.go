@@ -0,0 +1,60 @@
+package jsonrpc2
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+)
+
+// NewTLSListener creates a new Listener for TLS connections.
+//
+// For mutual TLS, set ClientAuth and ClientCAs of `config`.
+// Handlers can get the verified client certificate by `PeerCertificateFromContext`.
+func NewTLSListener(addr string, config *tls.Config) (Listener, error) {
+	listener, err := tls.Listen("tcp", addr, config)
+	if err != nil {
+		return nil, err
+	}
+	return NewListener(listener), nil
+}
+
+// TLSDialer creates a Dialer that connects to the address with TLS.
+//
+// For mutual TLS, set Certificates of `config`.
+// The Dialer can be used for `NewReconnectingClient`, or called directly to get a connection for `NewClient`.
+func TLSDialer(network, address string, config *tls.Config) Dialer {
+	d := tls.Dialer{Config: config}
+	return func(ctx context.Context) (io.ReadWriteCloser, error) {
+		return d.DialContext(ctx, network, address)
+	}
+}
+
+// TLSStateFromContext returns the TLS connection state of the connection that the request came from.
+//
+// It returns false if the request did not come from a TLS connection.
+func TLSStateFromContext(ctx context.Context) (tls.ConnectionState, bool) {
+	conn, ok := ConnFromContext(ctx)
+	if !ok {
+		return tls.ConnectionState{}, false
+	}
+	tc, ok := conn.(*tls.Conn)
+	if !ok {
+		return tls.ConnectionState{}, false
+	}
+	return tc.ConnectionState(), true
+}
+
+// PeerCertificateFromContext returns the verified certificate of the client that sent the request.
+//
+// It returns false if the request did not come from a TLS connection, or the client certificate is not verified.
+// Certificates are verified only if ClientAuth of the tls.Config is `tls.VerifyClientCertIfGiven` or `tls.RequireAndVerifyClientCert`.
+//
+// The subject and SANs are available as `Subject`, `DNSNames`, `EmailAddresses`, `IPAddresses`, and `URIs` of the certificate.
+func PeerCertificateFromContext(ctx context.Context) (*x509.Certificate, bool) {
+	state, ok := TLSStateFromContext(ctx)
+	if !ok || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+	return state.VerifiedChains[0][0], true
+}
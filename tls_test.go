@@ -0,0 +1,201 @@
+package jsonrpc2_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/macrat/go-jsonrpc2"
+)
+
+// TestCA is a certificate authority for tests.
+type TestCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func NewTestCA(t *testing.T) *TestCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %s", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %s", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return &TestCA{cert: cert, key: key, pool: pool}
+}
+
+// Issue issues a certificate for the common name and SANs.
+func (ca *TestCA) Issue(t *testing.T, cn string, usage x509.ExtKeyUsage, dnsNames []string, ips []net.IP) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn, Organization: []string{"go-jsonrpc2"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		DNSNames:     dnsNames,
+		IPAddresses:  ips,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %s", err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestTLSListener(t *testing.T) {
+	t.Parallel()
+
+	ca := NewTestCA(t)
+	serverCert := ca.Issue(t, "server", x509.ExtKeyUsageServerAuth, []string{"localhost"}, []net.IP{net.IPv4(127, 0, 0, 1)})
+	adminCert := ca.Issue(t, "admin", x509.ExtKeyUsageClientAuth, []string{"admin.example.com"}, nil)
+	userCert := ca.Issue(t, "user", x509.ExtKeyUsageClientAuth, []string{"user.example.com"}, nil)
+
+	l, err := jsonrpc2.NewTLSListener("127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    ca.pool,
+	})
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	addr := l.(interface{ Addr() net.Addr }).Addr().String()
+
+	// adminOnly is an authorization middleware that allows only the "admin" client.
+	adminOnly := func(next jsonrpc2.Handler) jsonrpc2.Handler {
+		return jsonrpc2.HandlerFunc(func(ctx context.Context, r jsonrpc2.RawRequest) (any, error) {
+			cert, ok := jsonrpc2.PeerCertificateFromContext(ctx)
+			if !ok || cert.Subject.CommonName != "admin" {
+				return nil, jsonrpc2.Error{Code: 403, Message: "Forbidden"}
+			}
+			return next.ServeJSONRPC2(ctx, r)
+		})
+	}
+
+	server := jsonrpc2.NewServer()
+	server.On("whoami", jsonrpc2.Call(func(ctx context.Context, _ any) (string, error) {
+		cert, ok := jsonrpc2.PeerCertificateFromContext(ctx)
+		if !ok {
+			return "", jsonrpc2.ErrInternalError
+		}
+		return cert.Subject.CommonName + " " + strings.Join(cert.DNSNames, ","), nil
+	}))
+	server.On("shutdown", jsonrpc2.Call(func(ctx context.Context, _ any) (string, error) {
+		return "ok", nil
+	}), adminOnly)
+
+	go server.Serve(l)
+	defer server.Close()
+
+	dial := func(t *testing.T, cert *tls.Certificate) *jsonrpc2.Client {
+		conf := &tls.Config{RootCAs: ca.pool, ServerName: "localhost"}
+		if cert != nil {
+			conf.Certificates = []tls.Certificate{*cert}
+		}
+
+		conn, err := jsonrpc2.TLSDialer("tcp", addr, conf)(context.Background())
+		if err != nil {
+			t.Fatalf("failed to dial: %s", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+
+		client := jsonrpc2.NewClient(conn)
+		t.Cleanup(func() { client.Close() })
+		return client
+	}
+
+	ctx := context.Background()
+
+	t.Run("admin", func(t *testing.T) {
+		client := dial(t, &adminCert)
+
+		var name string
+		if err := client.Call(ctx, "whoami", nil, &name); err != nil {
+			t.Fatalf("failed to call: %s", err)
+		} else if name != "admin admin.example.com" {
+			t.Errorf("unexpected peer: %q", name)
+		}
+
+		var result string
+		if err := client.Call(ctx, "shutdown", nil, &result); err != nil {
+			t.Errorf("failed to call: %s", err)
+		}
+	})
+
+	t.Run("user", func(t *testing.T) {
+		client := dial(t, &userCert)
+
+		var name string
+		if err := client.Call(ctx, "whoami", nil, &name); err != nil {
+			t.Fatalf("failed to call: %s", err)
+		} else if name != "user user.example.com" {
+			t.Errorf("unexpected peer: %q", name)
+		}
+
+		var result string
+		if err := client.Call(ctx, "shutdown", nil, &result); err == nil || err.Error() != "Forbidden (403)" {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("no-certificate", func(t *testing.T) {
+		client := dial(t, nil)
+
+		var name string
+		if err := client.Call(ctx, "whoami", nil, &name); err == nil {
+			t.Errorf("expected error but got nothing")
+		}
+	})
+}
+
+func TestPeerCertificateFromContext(t *testing.T) {
+	t.Parallel()
+
+	if _, ok := jsonrpc2.PeerCertificateFromContext(context.Background()); ok {
+		t.Errorf("context without connection should return false")
+	}
+	if _, ok := jsonrpc2.TLSStateFromContext(context.Background()); ok {
+		t.Errorf("context without connection should return false")
+	}
+}
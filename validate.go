@@ -0,0 +1,443 @@
+package jsonrpc2
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Validator is an interface for params types that validate themselves.
+//
+// Handlers created by `Call`, `Notify`, and so on validate params after decoding.
+// First, fields of structs are checked by `jsonrpc2` tags, which is a comma separated list of the following rules.
+//
+//	required     the value must not be a zero value
+//	min=N        numbers must be >= N, strings, slices, and maps must have length >= N
+//	max=N        numbers must be <= N, strings, slices, and maps must have length <= N
+//	enum=a|b|c   the value must be one of the listed values
+//	pattern=RE   strings must match the regular expression. This must be the last rule because RE can contain commas
+//
+// Rules other than required are checked for zero values as well, such as 0 and "".
+// Only nil pointers, slices, and maps are treated as absent and skip them,
+// so use a pointer for an optional field whose zero value does not satisfy the rules.
+// The `position` option for params by-position can be used together. See `positionalFields` for details.
+//
+//	type SearchParams struct {
+//		Query string  `json:"query" jsonrpc2:"required,max=100"`
+//		Sort  *string `json:"sort" jsonrpc2:"enum=asc|desc"`
+//	}
+//
+// Other tags such as `validate` of other validation libraries are not used.
+//
+// Then, Validate is called if the params type implements this interface and the tags are satisfied.
+// Nested values that implement Validator are also validated.
+// If Validate returns a `ValidationError`, the field paths in it are treated as relative to the value.
+type Validator interface {
+	Validate() error
+}
+
+// FieldError describes a failure of validation of a field.
+type FieldError struct {
+	// Field is the path to the field, such as "params.items[0].name".
+	Field string `json:"field"`
+
+	Message string `json:"message"`
+}
+
+// ValidationError is a list of failures of params validation.
+//
+// Handlers respond `ErrInvalidParams` with this value as `Data` when params validation fails.
+type ValidationError []FieldError
+
+func (e ValidationError) Error() string {
+	msgs := make([]string, len(e))
+	for i, f := range e {
+		msgs[i] = f.Field + ": " + f.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// fieldRule is a set of rules of a struct field given by the `jsonrpc2` tag.
+// See `Validator` for the syntax.
+type fieldRule struct {
+	index    int
+	name     string // name is empty for embedded structs.
+	required bool
+	min, max *float64
+	enum     []string
+	pattern  *regexp.Regexp
+}
+
+var structRulesCache sync.Map // map[reflect.Type]structRulesEntry
+
+type structRulesEntry struct {
+	rules []fieldRule
+	err   error
+}
+
+// structRules returns the rules of the fields of the struct type `t`.
+func structRules(t reflect.Type) ([]fieldRule, error) {
+	if e, ok := structRulesCache.Load(t); ok {
+		return e.(structRulesEntry).rules, e.(structRulesEntry).err
+	}
+
+	var rules []fieldRule
+	var err error
+	for i := 0; i < t.NumField() && err == nil; i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		var name string
+		if n, _, _ := strings.Cut(f.Tag.Get("json"), ","); n == "-" {
+			continue
+		} else if !f.Anonymous || n != "" {
+			name, _ = jsonFieldName(f)
+		}
+
+		r := fieldRule{index: i, name: name}
+		if tag := f.Tag.Get("jsonrpc2"); tag != "" {
+			if err = r.parse(f.Type, tag); err != nil {
+				err = fmt.Errorf("jsonrpc2: invalid jsonrpc2 tag of %s.%s: %w", t, f.Name, err)
+			}
+		}
+		rules = append(rules, r)
+	}
+
+	structRulesCache.Store(t, structRulesEntry{rules, err})
+	return rules, err
+}
+
+func (r *fieldRule) parse(t reflect.Type, tag string) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	for _, rule := range tagOptions(tag) {
+		key, value, _ := strings.Cut(rule, "=")
+
+		switch key {
+		case "position":
+			// position is not a validation rule, but an option for params by-position.
+		case "required":
+			r.required = true
+		case "min", "max":
+			if !isNumber(t) && !hasLength(t) {
+				return fmt.Errorf("%s is not supported for %s", key, t)
+			}
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", key, err)
+			}
+			if key == "min" {
+				r.min = &n
+			} else {
+				r.max = &n
+			}
+		case "enum":
+			if !isNumber(t) && t.Kind() != reflect.String {
+				return fmt.Errorf("enum is not supported for %s", t)
+			}
+			r.enum = strings.Split(value, "|")
+		case "pattern":
+			if t.Kind() != reflect.String {
+				return fmt.Errorf("pattern is not supported for %s", t)
+			}
+			re, err := regexp.Compile(value)
+			if err != nil {
+				return fmt.Errorf("invalid pattern: %w", err)
+			}
+			r.pattern = re
+		default:
+			return fmt.Errorf("unknown rule %q", key)
+		}
+	}
+	return nil
+}
+
+// tagOptions splits the `jsonrpc2` tag into options.
+// The pattern rule is always the last option because the regular expression can contain commas.
+func tagOptions(tag string) []string {
+	var opts []string
+	for tag != "" {
+		var opt string
+		if strings.HasPrefix(tag, "pattern=") {
+			opt, tag = tag, ""
+		} else {
+			opt, tag, _ = strings.Cut(tag, ",")
+		}
+		opts = append(opts, opt)
+	}
+	return opts
+}
+
+func isNumber(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func hasLength(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// check checks the value of the field, and reports failures to `v`.
+func (r fieldRule) check(v *validator, path string, fv reflect.Value) {
+	if r.required && fv.IsZero() {
+		v.fail(path, "is required")
+		return
+	}
+
+	// Nil values are absent, but zero values such as 0 and "" are present and checked by other rules.
+	for fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return
+		}
+		fv = fv.Elem()
+	}
+	if (fv.Kind() == reflect.Slice || fv.Kind() == reflect.Map) && fv.IsNil() {
+		return
+	}
+
+	if r.min != nil || r.max != nil {
+		r.checkRange(v, path, fv)
+	}
+
+	if r.enum != nil {
+		s := fmt.Sprint(fv.Interface())
+		found := false
+		for _, e := range r.enum {
+			if e == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "must be one of "+strings.Join(r.enum, ", "))
+		}
+	}
+
+	if r.pattern != nil && !r.pattern.MatchString(fv.String()) {
+		v.fail(path, "must match the pattern "+r.pattern.String())
+	}
+}
+
+func (r fieldRule) checkRange(v *validator, path string, fv reflect.Value) {
+	var n float64
+	var what string
+
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(fv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n = float64(fv.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = fv.Float()
+	case reflect.String:
+		n = float64(utf8.RuneCountInString(fv.String()))
+		what = "characters"
+	default:
+		n = float64(fv.Len())
+		what = "items"
+	}
+
+	format := func(x float64) string {
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	}
+
+	switch {
+	case r.min != nil && n < *r.min && what == "":
+		v.fail(path, "must be greater than or equal to "+format(*r.min))
+	case r.min != nil && n < *r.min:
+		v.fail(path, fmt.Sprintf("must have at least %s %s", format(*r.min), what))
+	case r.max != nil && n > *r.max && what == "":
+		v.fail(path, "must be less than or equal to "+format(*r.max))
+	case r.max != nil && n > *r.max:
+		v.fail(path, fmt.Sprintf("must have at most %s %s", format(*r.max), what))
+	}
+}
+
+// checkValidation checks that all validation rules in the type `t` are valid.
+func checkValidation(t reflect.Type) error {
+	return walkTypes(t, map[reflect.Type]bool{})
+}
+
+func walkTypes(t reflect.Type, visited map[reflect.Type]bool) error {
+	if visited[t] {
+		return nil
+	}
+	visited[t] = true
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return walkTypes(t.Elem(), visited)
+	case reflect.Struct:
+		rules, err := structRules(t)
+		if err != nil {
+			return err
+		}
+		for _, r := range rules {
+			if err := walkTypes(t.Field(r.index).Type, visited); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var validatorType = reflect.TypeFor[Validator]()
+
+type validator struct {
+	errs ValidationError
+}
+
+func (v *validator) fail(path, message string) {
+	v.errs = append(v.errs, FieldError{Field: path, Message: message})
+}
+
+// value validates `rv` and its children recursively.
+func (v *validator) value(path string, rv reflect.Value) {
+	if !rv.IsValid() {
+		return
+	}
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return
+		}
+		rv = rv.Elem()
+	}
+
+	before := len(v.errs)
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		// Tags are checked by checkParamsType when the handler is created, so errors are ignored here.
+		rules, _ := structRules(rv.Type())
+		for _, r := range rules {
+			p := joinPath(path, r.name)
+			fv := rv.Field(r.index)
+			r.check(v, p, fv)
+			v.value(p, fv)
+		}
+	case reflect.Slice, reflect.Array:
+		if mayHaveRules(rv.Type().Elem()) {
+			for i := 0; i < rv.Len(); i++ {
+				v.value(fmt.Sprintf("%s[%d]", path, i), rv.Index(i))
+			}
+		}
+	case reflect.Map:
+		if mayHaveRules(rv.Type().Elem()) {
+			keys := rv.MapKeys()
+			sort.Slice(keys, func(i, j int) bool {
+				return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+			})
+			for _, k := range keys {
+				v.value(joinPath(path, fmt.Sprint(k.Interface())), rv.MapIndex(k))
+			}
+		}
+	}
+
+	// Validate is called only if the tags are satisfied, so that it can rely on them.
+	if len(v.errs) == before {
+		v.custom(path, rv)
+	}
+}
+
+func (v *validator) custom(path string, rv reflect.Value) {
+	var val Validator
+	if rv.CanAddr() && rv.Addr().Type().Implements(validatorType) {
+		val = rv.Addr().Interface().(Validator)
+	} else if rv.Type().Implements(validatorType) {
+		val = rv.Interface().(Validator)
+	} else {
+		return
+	}
+
+	err := val.Validate()
+	if err == nil {
+		return
+	}
+
+	if verr, ok := err.(ValidationError); ok {
+		for _, f := range verr {
+			v.fail(joinPath(path, f.Field), f.Message)
+		}
+	} else {
+		v.fail(path, err.Error())
+	}
+}
+
+// mayHaveRules reports whether values of the type `t` can have something to validate.
+func mayHaveRules(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	}
+	return implements(t, validatorType)
+}
+
+func joinPath(path, name string) string {
+	switch {
+	case name == "":
+		return path
+	case path == "":
+		return name
+	case strings.HasPrefix(name, "["):
+		return path + name
+	default:
+		return path + "." + name
+	}
+}
+
+// validateParams validates decoded params.
+//
+// Each of `args` is a pointer to a decoded argument, and `paths` are the paths of them such as "params" or "params[0]".
+// It returns `ErrInvalidParams` with `ValidationError` as data if validation fails.
+func validateParams(paths []string, args ...any) error {
+	var v validator
+	for i, arg := range args {
+		v.value(paths[i], reflect.ValueOf(arg))
+	}
+
+	if len(v.errs) == 0 {
+		return nil
+	}
+
+	err := ErrInvalidParams
+	err.Data = v.errs
+	return err
+}
+
+// argPaths returns the paths of arguments for multi-argument handlers.
+func argPaths(data []byte, names []string, n int) []string {
+	data = bytes.TrimSpace(data)
+	byName := names != nil && len(data) > 0 && data[0] == '{'
+
+	paths := make([]string, n)
+	for i := range paths {
+		if byName {
+			paths[i] = "params." + names[i]
+		} else {
+			paths[i] = fmt.Sprintf("params[%d]", i)
+		}
+	}
+	return paths
+}
+
+// paramsPath is the path for single-argument handlers.
+var paramsPath = []string{"params"}
@@ -0,0 +1,286 @@
+package jsonrpc2_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/macrat/go-jsonrpc2"
+)
+
+type SignupParams struct {
+	Name  string   `json:"name" jsonrpc2:"required,min=2,max=8"`
+	Age   int      `json:"age" jsonrpc2:"min=0,max=150"`
+	Role  *string  `json:"role" jsonrpc2:"enum=admin|user"`
+	Email *string  `json:"email" jsonrpc2:"pattern=^[^@]+@[^@]+$"`
+	Tags  []string `json:"tags" jsonrpc2:"max=2"`
+	Pets  []Pet    `json:"pets"`
+}
+
+type Pet struct {
+	Kind *string `json:"kind" jsonrpc2:"required"`
+}
+
+type Range struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+func (r Range) Validate() error {
+	if r.From > r.To {
+		return jsonrpc2.ValidationError{{Field: "to", Message: "must be greater than or equal to from"}}
+	}
+	return nil
+}
+
+type Password string
+
+func (p *Password) Validate() error {
+	if len(*p) < 4 {
+		return errors.New("too short")
+	}
+	return nil
+}
+
+func TestValidation(t *testing.T) {
+	t.Parallel()
+
+	server := jsonrpc2.NewServer()
+	server.On("signup", jsonrpc2.Call(func(ctx context.Context, p SignupParams) (string, error) {
+		return "ok", nil
+	}))
+	server.On("range", jsonrpc2.Call(func(ctx context.Context, r Range) (string, error) {
+		return "ok", nil
+	}))
+	server.On("login", jsonrpc2.Call2(func(ctx context.Context, name string, pass Password) (string, error) {
+		return "ok", nil
+	}, jsonrpc2.ParamNames("name", "password")))
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client := jsonrpc2.NewHTTPClient(ts.URL, nil)
+
+	tests := []struct {
+		Name   string
+		Method string
+		Params any
+		Errors jsonrpc2.ValidationError
+	}{
+		{
+			Name:   "valid",
+			Method: "signup",
+			Params: map[string]any{"name": "alice", "age": 20, "role": "admin", "email": "a@example.com", "pets": []any{map[string]any{"kind": "cat"}}},
+		},
+		{
+			Name:   "missing-name",
+			Method: "signup",
+			Params: map[string]any{},
+			Errors: jsonrpc2.ValidationError{
+				{Field: "params.name", Message: "is required"},
+			},
+		},
+		{
+			Name:   "everything-wrong",
+			Method: "signup",
+			Params: map[string]any{
+				"name":  "a",
+				"age":   200,
+				"role":  "root",
+				"email": "invalid",
+				"tags":  []string{"a", "b", "c"},
+				"pets":  []any{map[string]any{"kind": "cat"}, map[string]any{}},
+			},
+			Errors: jsonrpc2.ValidationError{
+				{Field: "params.name", Message: "must have at least 2 characters"},
+				{Field: "params.age", Message: "must be less than or equal to 150"},
+				{Field: "params.role", Message: "must be one of admin, user"},
+				{Field: "params.email", Message: "must match the pattern ^[^@]+@[^@]+$"},
+				{Field: "params.tags", Message: "must have at most 2 items"},
+				{Field: "params.pets[1].kind", Message: "is required"},
+			},
+		},
+		{
+			Name:   "by-position",
+			Method: "signup",
+			Params: []any{"toolongname", -1},
+			Errors: jsonrpc2.ValidationError{
+				{Field: "params.name", Message: "must have at most 8 characters"},
+				{Field: "params.age", Message: "must be greater than or equal to 0"},
+			},
+		},
+		{
+			Name:   "validator",
+			Method: "range",
+			Params: Range{From: 2, To: 1},
+			Errors: jsonrpc2.ValidationError{
+				{Field: "params.to", Message: "must be greater than or equal to from"},
+			},
+		},
+		{
+			Name:   "multi-by-position",
+			Method: "login",
+			Params: []any{"alice", "abc"},
+			Errors: jsonrpc2.ValidationError{
+				{Field: "params[1]", Message: "too short"},
+			},
+		},
+		{
+			Name:   "multi-by-name",
+			Method: "login",
+			Params: map[string]any{"name": "alice", "password": "abc"},
+			Errors: jsonrpc2.ValidationError{
+				{Field: "params.password", Message: "too short"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			var result string
+			err := client.Call(context.Background(), tt.Method, tt.Params, &result)
+
+			if tt.Errors == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+
+			var rpcErr *jsonrpc2.Error
+			if !errors.As(err, &rpcErr) {
+				t.Fatalf("unexpected error: %#v", err)
+			}
+			if rpcErr.Code != jsonrpc2.InvalidParamsCode {
+				t.Errorf("unexpected error code: %d", rpcErr.Code)
+			}
+
+			var want []any
+			for _, e := range tt.Errors {
+				want = append(want, map[string]any{"field": e.Field, "message": e.Message})
+			}
+			if diff := cmp.Diff(want, rpcErr.Data); diff != "" {
+				t.Errorf("unexpected error data:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidation_zeroValues(t *testing.T) {
+	t.Parallel()
+
+	type Params struct {
+		Count  int               `json:"count" jsonrpc2:"min=1"`
+		Level  *int              `json:"level" jsonrpc2:"max=-1"`
+		Mode   string            `json:"mode" jsonrpc2:"enum=on|off"`
+		Code   string            `json:"code" jsonrpc2:"pattern=^[a-z]+$"`
+		Items  []string          `json:"items" jsonrpc2:"min=1"`
+		Labels map[string]string `json:"labels" jsonrpc2:"min=1"`
+	}
+
+	h := jsonrpc2.Call(func(ctx context.Context, p Params) (string, error) {
+		return "ok", nil
+	})
+
+	tests := []struct {
+		Name   string
+		Params string
+		Errors jsonrpc2.ValidationError
+	}{
+		{
+			// Zero values are present, so the rules are checked for them.
+			Name:   "zero",
+			Params: `{"count":0,"level":0,"mode":"","code":"","items":[],"labels":{}}`,
+			Errors: jsonrpc2.ValidationError{
+				{Field: "params.count", Message: "must be greater than or equal to 1"},
+				{Field: "params.level", Message: "must be less than or equal to -1"},
+				{Field: "params.mode", Message: "must be one of on, off"},
+				{Field: "params.code", Message: "must match the pattern ^[a-z]+$"},
+				{Field: "params.items", Message: "must have at least 1 items"},
+				{Field: "params.labels", Message: "must have at least 1 items"},
+			},
+		},
+		{
+			// Nil pointers, slices, and maps are absent, so the rules are skipped.
+			Name:   "nil",
+			Params: `{"count":1,"level":null,"mode":"on","code":"a","items":null}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			_, err := h.ServeJSONRPC2(context.Background(), jsonrpc2.RawRequest{Method: "test", Params: []byte(tt.Params)})
+
+			if tt.Errors == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+
+			var rpcErr jsonrpc2.Error
+			if !errors.As(err, &rpcErr) {
+				t.Fatalf("unexpected error: %#v", err)
+			}
+			if diff := cmp.Diff(tt.Errors, rpcErr.Data); diff != "" {
+				t.Errorf("unexpected error data:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidation_invalidTag(t *testing.T) {
+	t.Parallel()
+
+	type Params struct {
+		Name string `jsonrpc2:"min=abc"`
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("expected panic")
+		}
+	}()
+
+	jsonrpc2.Call(func(ctx context.Context, p Params) (string, error) {
+		return "", nil
+	})
+}
+
+func TestValidation_otherTags(t *testing.T) {
+	t.Parallel()
+
+	// Tags for other validation libraries are ignored.
+	type Params struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+
+	h := jsonrpc2.Call(func(ctx context.Context, p Params) (string, error) {
+		return p.Email, nil
+	})
+
+	result, err := h.ServeJSONRPC2(context.Background(), jsonrpc2.RawRequest{Method: "test", Params: []byte(`{}`)})
+	if err != nil || result != "" {
+		t.Errorf("unexpected result: %v, %v", result, err)
+	}
+}
+
+func TestValidation_withPosition(t *testing.T) {
+	t.Parallel()
+
+	type Params struct {
+		Code string `json:"code" jsonrpc2:"position=0,required,pattern=^[a-z]{1,3}$"`
+	}
+
+	h := jsonrpc2.Call(func(ctx context.Context, p Params) (string, error) {
+		return p.Code, nil
+	})
+
+	RunParamsTests(t, resultString, []ParamsTest{
+		{"valid", h, `["abc"]`, "abc", 0},
+		{"invalid", h, `["abcd"]`, "", jsonrpc2.InvalidParamsCode},
+		{"missing", h, `[]`, "", jsonrpc2.InvalidParamsCode},
+	})
+}
@@ -0,0 +1,705 @@
+package jsonrpc2
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	// ErrWebSocketHandshake is returned by DialWebSocket if the server does not accept the WebSocket handshake.
+	ErrWebSocketHandshake = errors.New("jsonrpc2: websocket handshake failed")
+
+	errWebSocketProtocol = errors.New("jsonrpc2: websocket protocol error")
+)
+
+// websocketGUID is the magic string to calculate Sec-WebSocket-Accept, defined by RFC 6455.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xA
+)
+
+const (
+	wsCloseNormal        = 1000
+	wsCloseGoingAway     = 1001
+	wsCloseProtocolError = 1002
+	wsCloseTooLarge      = 1009
+)
+
+// WebSocketOption is a type for options of WebSocket connections.
+//
+// The options are used by `NewWebSocketListener`, `DialWebSocket`, and `WebSocketDialer`.
+type WebSocketOption func(*webSocketConfig)
+
+type webSocketConfig struct {
+	pingInterval   time.Duration
+	maxMessageSize int64
+	checkOrigin    func(*http.Request) bool
+	header         http.Header
+	tlsConfig      *tls.Config
+}
+
+func newWebSocketConfig(opts []WebSocketOption) webSocketConfig {
+	conf := webSocketConfig{
+		pingInterval:   30 * time.Second,
+		maxMessageSize: 10 << 20,
+		checkOrigin:    sameOrigin,
+	}
+	for _, opt := range opts {
+		opt(&conf)
+	}
+	return conf
+}
+
+// WithPingInterval specifies the interval to send ping frames for keepalive.
+// If this option is not specified, the default value is 30 seconds.
+//
+// The connection is closed if nothing is received from the peer in twice of the interval.
+// Zero disables both pings and the timeout.
+func WithPingInterval(d time.Duration) WebSocketOption {
+	return func(c *webSocketConfig) {
+		c.pingInterval = d
+	}
+}
+
+// WithMaxMessageSize specifies the maximum size of a received message in bytes.
+// If this option is not specified, the default value is 10 MiB.
+//
+// The connection is closed if the peer sends a larger message.
+//
+// n must be greater than 0.
+func WithMaxMessageSize(n int64) WebSocketOption {
+	if n <= 0 {
+		panic("jsonrpc2: n for jsonrpc2.WithMaxMessageSize must be greater than 0")
+	}
+	return func(c *webSocketConfig) {
+		c.maxMessageSize = n
+	}
+}
+
+// WithOriginCheck specifies a function to check the Origin header of handshake requests.
+// This option is used only by `NewWebSocketListener`.
+//
+// By default, the listener accepts requests without Origin header, and requests that the Origin is the same as the Host.
+// Please use this option to accept connections from web pages in other origins.
+func WithOriginCheck(f func(*http.Request) bool) WebSocketOption {
+	return func(c *webSocketConfig) {
+		c.checkOrigin = f
+	}
+}
+
+// WithHandshakeHeader specifies additional headers for handshake requests, such as Authorization.
+// This option is used only by `DialWebSocket` and `WebSocketDialer`.
+func WithHandshakeHeader(h http.Header) WebSocketOption {
+	return func(c *webSocketConfig) {
+		c.header = h
+	}
+}
+
+// WithWebSocketTLSConfig specifies the TLS configuration to connect to "wss://" URLs.
+// This option is used only by `DialWebSocket` and `WebSocketDialer`.
+//
+// Use this option to trust a private CA by RootCAs, or to send a client certificate for mutual TLS by Certificates.
+// If ServerName of the config is empty, the host name of the URL is used.
+func WithWebSocketTLSConfig(config *tls.Config) WebSocketOption {
+	return func(c *webSocketConfig) {
+		c.tlsConfig = config
+	}
+}
+
+// sameOrigin reports whether the request has no Origin header, or the Origin is the same as the Host.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// headerContains reports whether the comma separated header has the token.
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func websocketAccept(key string) string {
+	h := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// WebSocketListener is a Listener for WebSocket connections.
+//
+// This is also an http.Handler that upgrades requests to WebSocket connections.
+// Each JSON-RPC 2.0 message or batch is sent as a single text message.
+//
+//	l := jsonrpc2.NewWebSocketListener()
+//	http.Handle("/ws", l)
+//	go server.Serve(l)
+type WebSocketListener struct {
+	conf      webSocketConfig
+	conns     chan *wsConn
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewWebSocketListener creates a new WebSocketListener.
+func NewWebSocketListener(opts ...WebSocketOption) *WebSocketListener {
+	return &WebSocketListener{
+		conf:  newWebSocketConfig(opts),
+		conns: make(chan *wsConn),
+		done:  make(chan struct{}),
+	}
+}
+
+// ServeHTTP implements http.Handler.
+//
+// It upgrades the request to a WebSocket connection, and passes it to `Accept`.
+func (l *WebSocketListener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-l.done:
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+		return
+	default:
+	}
+
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		w.Header().Set("Upgrade", "websocket")
+		http.Error(w, "Upgrade Required", http.StatusUpgradeRequired)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if k, err := base64.StdEncoding.DecodeString(key); err != nil || len(k) != 16 {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	if !l.conf.checkOrigin(r) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	conn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	// Clear deadlines that are set by http.Server.
+	conn.SetDeadline(time.Time{})
+
+	res := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + websocketAccept(key) + "\r\n\r\n"
+	if _, err := conn.Write([]byte(res)); err != nil {
+		conn.Close()
+		return
+	}
+
+	ws := newWSConn(conn, brw.Reader, false, l.conf)
+
+	select {
+	case l.conns <- ws:
+	case <-l.done:
+		ws.fail(wsCloseGoingAway, "server is shutting down")
+	}
+}
+
+// Accept implements the Listener interface.
+func (l *WebSocketListener) Accept() (io.ReadWriter, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close implements the Listener interface.
+//
+// It does not close the http.Server, but new requests are rejected after this.
+func (l *WebSocketListener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.done)
+	})
+	return nil
+}
+
+// DialWebSocket connects to the WebSocket server at the URL such as "ws://localhost:8080/ws" or "wss://example.com/ws".
+//
+// The returned connection sends each JSON-RPC 2.0 message as a single text message.
+// It can be used with `NewClient` or `NewConn`.
+func DialWebSocket(ctx context.Context, rawURL string, opts ...WebSocketOption) (io.ReadWriteCloser, error) {
+	conf := newWebSocketConfig(opts)
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	addr := u.Host
+	switch u.Scheme {
+	case "ws":
+		if u.Port() == "" {
+			addr = net.JoinHostPort(u.Hostname(), "80")
+		}
+	case "wss":
+		if u.Port() == "" {
+			addr = net.JoinHostPort(u.Hostname(), "443")
+		}
+	default:
+		return nil, fmt.Errorf("jsonrpc2: unsupported scheme for WebSocket: %q", u.Scheme)
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	ws, err := websocketHandshake(ctx, conn, u, conf)
+	if err != nil {
+		conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	return ws, nil
+}
+
+func websocketHandshake(ctx context.Context, conn net.Conn, u *url.URL, conf webSocketConfig) (*wsConn, error) {
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	if u.Scheme == "wss" {
+		var tlsConf *tls.Config
+		if conf.tlsConfig != nil {
+			tlsConf = conf.tlsConfig.Clone()
+		} else {
+			tlsConf = &tls.Config{}
+		}
+		if tlsConf.ServerName == "" {
+			tlsConf.ServerName = u.Hostname()
+		}
+		tlsConn := tls.Client(conn, tlsConf)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	var nonce [16]byte
+	rand.Read(nonce[:])
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Host:       u.Host,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     conf.header.Clone(),
+	}
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s", ErrWebSocketHandshake, resp.Status)
+	}
+	if !headerContains(resp.Header, "Upgrade", "websocket") || !headerContains(resp.Header, "Connection", "upgrade") {
+		return nil, fmt.Errorf("%w: missing upgrade headers", ErrWebSocketHandshake)
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != websocketAccept(key) {
+		return nil, fmt.Errorf("%w: invalid Sec-WebSocket-Accept", ErrWebSocketHandshake)
+	}
+
+	if !stop() {
+		return nil, ctx.Err()
+	}
+
+	return newWSConn(conn, br, true, conf), nil
+}
+
+// WebSocketDialer creates a Dialer for `ReconnectingClient` that connects to the WebSocket server.
+//
+// See `DialWebSocket` for the details.
+func WebSocketDialer(url string, opts ...WebSocketOption) Dialer {
+	return func(ctx context.Context) (io.ReadWriteCloser, error) {
+		return DialWebSocket(ctx, url, opts...)
+	}
+}
+
+// wsConn is a WebSocket connection.
+//
+// It implements FrameReader and FrameWriter, so each message is sent as a single WebSocket message.
+// It also implements io.ReadWriter; each Write call sends a text message,
+// and Read reads the received messages as a concatenated stream.
+type wsConn struct {
+	conn   net.Conn
+	r      *bufio.Reader
+	client bool
+	conf   webSocketConfig
+
+	wmu       sync.Mutex
+	closeSent bool
+
+	// buf is the unread part of the current message for Read.
+	buf []byte
+
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newWSConn(conn net.Conn, r *bufio.Reader, client bool, conf webSocketConfig) *wsConn {
+	c := &wsConn{
+		conn:   conn,
+		r:      r,
+		client: client,
+		conf:   conf,
+		done:   make(chan struct{}),
+	}
+	if conf.pingInterval > 0 {
+		go c.keepalive()
+	}
+	return c
+}
+
+func (c *wsConn) keepalive() {
+	ticker := time.NewTicker(c.conf.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if err := c.writeFrame(wsPing, nil); err != nil {
+				c.Close()
+				return
+			}
+		}
+	}
+}
+
+// ReadFrame implements FrameReader.
+//
+// Control frames are handled while reading.
+// Binary messages and text messages that are not valid UTF-8 are reported as ErrInvalidFrame.
+func (c *wsConn) ReadFrame() ([]byte, error) {
+	var msg []byte
+	var opcode byte
+
+	for {
+		fin, op, payload, err := c.readFrame(c.conf.maxMessageSize - int64(len(msg)))
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case wsPing:
+			c.writeFrame(wsPong, payload)
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			code := uint16(wsCloseNormal)
+			if len(payload) >= 2 {
+				code = binary.BigEndian.Uint16(payload)
+			}
+			c.writeClose(code, "")
+			c.Close()
+			return nil, io.EOF
+		case wsText, wsBinary:
+			if opcode != 0 {
+				return nil, c.fail(wsCloseProtocolError, "unexpected new message in fragmented message")
+			}
+			opcode = op
+			msg = payload
+		case wsContinuation:
+			if opcode == 0 {
+				return nil, c.fail(wsCloseProtocolError, "unexpected continuation frame")
+			}
+			msg = append(msg, payload...)
+		default:
+			return nil, c.fail(wsCloseProtocolError, fmt.Sprintf("unknown opcode %d", op))
+		}
+
+		if !fin {
+			continue
+		}
+		if opcode == wsBinary {
+			return nil, fmt.Errorf("%w: binary message is not supported", ErrInvalidFrame)
+		}
+		if !utf8.Valid(msg) {
+			return nil, fmt.Errorf("%w: text message is not valid UTF-8", ErrInvalidFrame)
+		}
+		return msg, nil
+	}
+}
+
+// readFrame reads a single WebSocket frame.
+// The payload of data frames must not be larger than `limit`.
+func (c *wsConn) readFrame(limit int64) (fin bool, op byte, payload []byte, err error) {
+	if c.conf.pingInterval > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(2 * c.conf.pingInterval))
+	}
+
+	var head [2]byte
+	if _, err := io.ReadFull(c.r, head[:]); err != nil {
+		return false, 0, nil, c.readError(err)
+	}
+
+	fin = head[0]&0x80 != 0
+	op = head[0] & 0x0f
+	masked := head[1]&0x80 != 0
+
+	if head[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(wsCloseProtocolError, "reserved bits are set")
+	}
+	// Frames from the client must be masked, and frames from the server must not be masked.
+	if masked == c.client {
+		return false, 0, nil, c.fail(wsCloseProtocolError, "invalid mask bit")
+	}
+
+	n := uint64(head[1] & 0x7f)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, c.readError(err)
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, c.readError(err)
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if op&0x8 != 0 {
+		if n > 125 || !fin {
+			return false, 0, nil, c.fail(wsCloseProtocolError, "invalid control frame")
+		}
+	} else if limit < 0 || n > uint64(limit) {
+		return false, 0, nil, c.fail(wsCloseTooLarge, "message is too large")
+	}
+
+	var key [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.r, key[:]); err != nil {
+			return false, 0, nil, c.readError(err)
+		}
+	}
+
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return false, 0, nil, c.readError(err)
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= key[i%4]
+		}
+	}
+
+	return fin, op, payload, nil
+}
+
+// readError closes the connection after an I/O error.
+func (c *wsConn) readError(err error) error {
+	var nerr net.Error
+	if errors.As(err, &nerr) && nerr.Timeout() {
+		c.fail(wsCloseGoingAway, "ping timeout")
+	} else {
+		c.Close()
+	}
+	return err
+}
+
+// fail closes the connection with the status code, and returns an error that describes the reason.
+func (c *wsConn) fail(code uint16, reason string) error {
+	c.writeClose(code, reason)
+	c.Close()
+	return fmt.Errorf("%w: %s", errWebSocketProtocol, reason)
+}
+
+// WriteFrame implements FrameWriter.
+func (c *wsConn) WriteFrame(data []byte) error {
+	return c.writeFrame(wsText, data)
+}
+
+// Read implements io.Reader.
+func (c *wsConn) Read(p []byte) (int, error) {
+	if len(c.buf) == 0 {
+		msg, err := c.ReadFrame()
+		if err != nil {
+			return 0, err
+		}
+		c.buf = msg
+	}
+
+	n := copy(p, c.buf)
+	c.buf = c.buf[n:]
+	return n, nil
+}
+
+// Write implements io.Writer. Each call sends a single text message.
+func (c *wsConn) Write(p []byte) (int, error) {
+	if err := c.writeFrame(wsText, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// wsControlTimeout is the write timeout of control frames while keepalive is disabled.
+const wsControlTimeout = 10 * time.Second
+
+func (c *wsConn) writeFrame(op byte, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	if c.closeSent {
+		return net.ErrClosed
+	}
+
+	// Writes hold the lock, so a stalled peer would block all other writers without deadlines.
+	// While keepalive is enabled, the peer is treated as dead after twice of the ping interval, same as reading.
+	var deadline time.Time
+	switch {
+	case c.conf.pingInterval > 0:
+		deadline = time.Now().Add(2 * c.conf.pingInterval)
+	case op&0x8 != 0:
+		deadline = time.Now().Add(wsControlTimeout)
+	}
+	return c.writeFrameLocked(op, payload, deadline)
+}
+
+func (c *wsConn) writeFrameLocked(op byte, payload []byte, deadline time.Time) error {
+	buf := make([]byte, 0, 14+len(payload))
+	buf = append(buf, 0x80|op)
+
+	var mask byte
+	if c.client {
+		mask = 0x80
+	}
+
+	switch n := len(payload); {
+	case n <= 125:
+		buf = append(buf, mask|byte(n))
+	case n <= 0xffff:
+		buf = append(buf, mask|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, mask|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(n))
+	}
+
+	if c.client {
+		var key [4]byte
+		rand.Read(key[:])
+		buf = append(buf, key[:]...)
+		for i, b := range payload {
+			buf = append(buf, b^key[i%4])
+		}
+	} else {
+		buf = append(buf, payload...)
+	}
+
+	c.conn.SetWriteDeadline(deadline)
+	if _, err := c.conn.Write(buf); err != nil {
+		// A partially written frame breaks the stream, so the connection cannot be used anymore.
+		c.closeSent = true
+		c.conn.Close()
+		return err
+	}
+	return nil
+}
+
+// writeClose sends a close frame if it is not sent yet.
+func (c *wsConn) writeClose(code uint16, reason string) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	if c.closeSent {
+		return
+	}
+	c.closeSent = true
+
+	payload := binary.BigEndian.AppendUint16(nil, code)
+	payload = append(payload, reason...)
+	c.writeFrameLocked(wsClose, payload, time.Now().Add(time.Second))
+}
+
+// LocalAddr implements AcceptedConn.
+func (c *wsConn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// RemoteAddr implements AcceptedConn.
+func (c *wsConn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// Close sends a close frame and closes the connection.
+func (c *wsConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.writeClose(wsCloseNormal, "")
+		c.closeErr = c.conn.Close()
+	})
+	return c.closeErr
+}
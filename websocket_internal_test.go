@@ -0,0 +1,139 @@
+package jsonrpc2
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+)
+
+// opcodeConn is a net.Conn that reports the opcodes of written WebSocket frames.
+type opcodeConn struct {
+	net.Conn
+	opcodes chan byte
+}
+
+func (c opcodeConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	if err == nil && len(p) > 0 {
+		select {
+		case c.opcodes <- p[0] & 0x0F:
+		default:
+		}
+	}
+	return n, err
+}
+
+// newWSPipe creates a pair of connected WebSocket connections.
+// The returned channel receives opcodes of frames that are written by the server side.
+func newWSPipe(t *testing.T, server, client []WebSocketOption) (*wsConn, *wsConn, <-chan byte) {
+	t.Helper()
+
+	s, c := net.Pipe()
+	opcodes := make(chan byte, 100)
+
+	sc := newWSConn(opcodeConn{s, opcodes}, bufio.NewReader(s), false, newWebSocketConfig(server))
+	cc := newWSConn(c, bufio.NewReader(c), true, newWebSocketConfig(client))
+	t.Cleanup(func() {
+		sc.Close()
+		cc.Close()
+	})
+
+	return sc, cc, opcodes
+}
+
+// readLoop reads frames from `c` in background, and returns a channel that is closed when reading fails.
+func readLoop(c *wsConn) (<-chan []byte, <-chan error) {
+	frames := make(chan []byte, 10)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			frame, err := c.ReadFrame()
+			if err != nil {
+				errs <- err
+				return
+			}
+			frames <- frame
+		}
+	}()
+	return frames, errs
+}
+
+func TestWebSocket_keepalive(t *testing.T) {
+	t.Parallel()
+
+	t.Run("alive", func(t *testing.T) {
+		t.Parallel()
+
+		// The client does not send pings, but it responds to pings from the server while reading.
+		server, client, opcodes := newWSPipe(t, []WebSocketOption{WithPingInterval(100 * time.Millisecond)}, []WebSocketOption{WithPingInterval(0)})
+		_, serverErr := readLoop(server)
+		frames, _ := readLoop(client)
+
+		// The server closes the connection if a pong is not received within twice of the interval.
+		// So the connection is still alive after several pings are answered.
+		for pings := 0; pings < 5; {
+			select {
+			case op := <-opcodes:
+				if op == wsPing {
+					pings++
+				}
+			case err := <-serverErr:
+				t.Fatalf("the server closed the connection after %d pings: %s", pings, err)
+			case <-time.After(10 * time.Second):
+				t.Fatalf("the server did not send pings")
+			}
+		}
+
+		if err := server.WriteFrame([]byte("hello")); err != nil {
+			t.Fatalf("failed to write: %s", err)
+		}
+		select {
+		case frame := <-frames:
+			if string(frame) != "hello" {
+				t.Errorf("unexpected frame: %q", frame)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("the client did not receive the frame")
+		}
+	})
+
+	t.Run("dead", func(t *testing.T) {
+		t.Parallel()
+
+		// The client does not read, so pings from the server are not answered.
+		server, _, _ := newWSPipe(t, []WebSocketOption{WithPingInterval(100 * time.Millisecond)}, []WebSocketOption{WithPingInterval(0)})
+		_, serverErr := readLoop(server)
+
+		select {
+		case <-serverErr:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("the server did not close the connection")
+		}
+	})
+
+	t.Run("stalled", func(t *testing.T) {
+		t.Parallel()
+
+		// The client does not read, so writes of the server are blocked.
+		server, _, _ := newWSPipe(t, []WebSocketOption{WithPingInterval(100 * time.Millisecond)}, []WebSocketOption{WithPingInterval(0)})
+
+		errs := make(chan error, 3)
+		for range 3 {
+			go func() {
+				errs <- server.WriteFrame([]byte("hello"))
+			}()
+		}
+
+		for range 3 {
+			select {
+			case err := <-errs:
+				if err == nil {
+					t.Errorf("expected an error to write to the stalled peer")
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatalf("writing to the stalled peer is blocked")
+			}
+		}
+	})
+}
@@ -0,0 +1,252 @@
+package jsonrpc2_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/macrat/go-jsonrpc2"
+)
+
+func startWebSocketServer(t *testing.T, opts ...jsonrpc2.WebSocketOption) string {
+	t.Helper()
+
+	server := jsonrpc2.NewServer()
+	server.On("sum", jsonrpc2.Call(func(ctx context.Context, xs []int) (int, error) {
+		sum := 0
+		for _, x := range xs {
+			sum += x
+		}
+		return sum, nil
+	}))
+
+	l := jsonrpc2.NewWebSocketListener(opts...)
+	ts := httptest.NewServer(l)
+	go server.Serve(l)
+
+	t.Cleanup(func() {
+		server.Close()
+		ts.Close()
+	})
+
+	return "ws" + strings.TrimPrefix(ts.URL, "http")
+}
+
+func TestWebSocket(t *testing.T) {
+	t.Parallel()
+
+	url := startWebSocketServer(t)
+	ctx := context.Background()
+
+	conn, err := jsonrpc2.DialWebSocket(ctx, url)
+	if err != nil {
+		t.Fatalf("failed to dial: %s", err)
+	}
+	defer conn.Close()
+
+	client := jsonrpc2.NewClient(conn)
+	defer client.Close()
+
+	var sum int
+	if err := client.Call(ctx, "sum", []int{1, 2, 3}, &sum); err != nil {
+		t.Fatalf("failed to call: %s", err)
+	} else if sum != 6 {
+		t.Errorf("unexpected result: %d", sum)
+	}
+
+	res, err := client.Batch(ctx, []jsonrpc2.BatchRequest{
+		{Method: "sum", Params: []int{1, 2}},
+		{Method: "sum", Params: []int{3, 4}},
+	})
+	if err != nil {
+		t.Fatalf("failed to batch: %s", err)
+	}
+	if len(res) != 2 || string(res[0].Result) != "3" || string(res[1].Result) != "7" {
+		t.Errorf("unexpected batch response: %v", res)
+	}
+}
+
+// sortBatchByID sorts responses in a batch by ID, for comparing batches without depending on the order.
+// A single response is returned as-is.
+func sortBatchByID(t *testing.T, data []byte) string {
+	t.Helper()
+
+	var batch []json.RawMessage
+	if json.Unmarshal(data, &batch) != nil {
+		return string(data)
+	}
+
+	type response struct {
+		ID   string
+		Data string
+	}
+	resps := make([]response, len(batch))
+	for i, r := range batch {
+		var res struct {
+			ID json.RawMessage `json:"id"`
+		}
+		if err := json.Unmarshal(r, &res); err != nil {
+			t.Fatalf("failed to parse response: %s", err)
+		}
+		resps[i] = response{string(res.ID), string(r)}
+	}
+	sort.Slice(resps, func(i, j int) bool { return resps[i].ID < resps[j].ID })
+
+	sorted := make([]string, len(resps))
+	for i, r := range resps {
+		sorted[i] = r.Data
+	}
+	return "[" + strings.Join(sorted, ",") + "]"
+}
+
+func TestWebSocket_messageFraming(t *testing.T) {
+	t.Parallel()
+
+	url := startWebSocketServer(t)
+
+	conn, err := jsonrpc2.DialWebSocket(context.Background(), url)
+	if err != nil {
+		t.Fatalf("failed to dial: %s", err)
+	}
+	defer conn.Close()
+
+	fr, ok := conn.(jsonrpc2.FrameReader)
+	if !ok {
+		t.Fatalf("connection does not implement FrameReader")
+	}
+
+	tests := []struct {
+		Request  string
+		Response string
+	}{
+		{`{"jsonrpc":"2.0","method":"sum","params":[1,2],"id":1}`, `{"jsonrpc":"2.0","result":3,"id":1}`},
+		{
+			`[{"jsonrpc":"2.0","method":"sum","params":[1],"id":1},{"jsonrpc":"2.0","method":"sum","params":[2],"id":2}]`,
+			`[{"jsonrpc":"2.0","result":1,"id":1},{"jsonrpc":"2.0","result":2,"id":2}]`,
+		},
+		{`{"jsonrpc":"2.0",`, `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`},
+		{`{"jsonrpc":"2.0","method":"sum","params":[3],"id":3}`, `{"jsonrpc":"2.0","result":3,"id":3}`},
+	}
+
+	for _, tt := range tests {
+		if _, err := conn.Write([]byte(tt.Request)); err != nil {
+			t.Fatalf("failed to write: %s", err)
+		}
+
+		frame, err := fr.ReadFrame()
+		if err != nil {
+			t.Fatalf("failed to read: %s", err)
+		}
+		// Responses in a batch can be in any order.
+		if sortBatchByID(t, frame) != sortBatchByID(t, []byte(tt.Response)) {
+			t.Errorf("unexpected response to %s\nexpected: %s\n but got: %s", tt.Request, tt.Response, frame)
+		}
+	}
+}
+
+func TestWebSocketListener_handshake(t *testing.T) {
+	t.Parallel()
+
+	url := startWebSocketServer(t)
+	ctx := context.Background()
+
+	_, err := jsonrpc2.DialWebSocket(ctx, url, jsonrpc2.WithHandshakeHeader(http.Header{
+		"Origin": {"http://evil.example.com"},
+	}))
+	if !errors.Is(err, jsonrpc2.ErrWebSocketHandshake) {
+		t.Errorf("unexpected error for cross-origin request: %v", err)
+	}
+
+	resp, err := http.Get("http" + strings.TrimPrefix(url, "ws"))
+	if err != nil {
+		t.Fatalf("failed to send request: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUpgradeRequired {
+		t.Errorf("unexpected status for non-upgrade request: %s", resp.Status)
+	}
+}
+
+func TestWebSocketDialer(t *testing.T) {
+	t.Parallel()
+
+	url := startWebSocketServer(t)
+	ctx := context.Background()
+
+	client := jsonrpc2.NewReconnectingClient(jsonrpc2.WebSocketDialer(url))
+	defer client.Close()
+
+	var sum int
+	if err := client.Call(ctx, "sum", []int{4, 5}, &sum); err != nil {
+		t.Fatalf("failed to call: %s", err)
+	} else if sum != 9 {
+		t.Errorf("unexpected result: %d", sum)
+	}
+}
+
+func TestDialWebSocket_tls(t *testing.T) {
+	t.Parallel()
+
+	server := jsonrpc2.NewServer()
+	server.On("sum", jsonrpc2.Call(func(ctx context.Context, xs []int) (int, error) {
+		return len(xs), nil
+	}))
+
+	l := jsonrpc2.NewWebSocketListener()
+	ts := httptest.NewTLSServer(l)
+	go server.Serve(l)
+	t.Cleanup(func() {
+		server.Close()
+		ts.Close()
+	})
+
+	url := "wss" + strings.TrimPrefix(ts.URL, "https")
+	ctx := context.Background()
+
+	// The certificate of the test server is not trusted by default.
+	if _, err := jsonrpc2.DialWebSocket(ctx, url); err == nil {
+		t.Fatalf("expected an error for untrusted certificate")
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ts.Certificate())
+
+	conn, err := jsonrpc2.DialWebSocket(ctx, url, jsonrpc2.WithWebSocketTLSConfig(&tls.Config{RootCAs: pool}))
+	if err != nil {
+		t.Fatalf("failed to dial: %s", err)
+	}
+	defer conn.Close()
+
+	client := jsonrpc2.NewClient(conn)
+	defer client.Close()
+
+	var n int
+	if err := client.Call(ctx, "sum", []int{1, 2}, &n); err != nil {
+		t.Fatalf("failed to call: %s", err)
+	} else if n != 2 {
+		t.Errorf("unexpected result: %d", n)
+	}
+}
+
+func TestWithMaxMessageSize_invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, n := range []int64{0, -1} {
+		t.Run(strconv.FormatInt(n, 10), func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic")
+				}
+			}()
+			jsonrpc2.WithMaxMessageSize(n)
+		})
+	}
+}